@@ -0,0 +1,307 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nuid"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// defaultAsyncTimeout - timeout of asynchronous calls whose context has no deadline
+const defaultAsyncTimeout = time.Minute
+
+// Future - result of the asynchronous call, the response is decoded into the value passed to RequestAsync
+type Future struct {
+	c         *Client
+	requestID string
+	response  interface{}
+	done      chan struct{}
+	once      sync.Once
+	err       error
+	callback  func(err error)
+	finish    func(err error)
+	// timer - completes the call by the deadline, guarded by the mutex of replyMux
+	timer *time.Timer
+}
+
+// Done - returns the channel which is closed when the call is completed
+func (f *Future) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait - waits for the completion of the call, returns its error
+func (f *Future) Wait() error {
+	<-f.done
+	return f.err
+}
+
+// Response - returns the response passed to RequestAsync, it is filled after the completion
+func (f *Future) Response() interface{} {
+	return f.response
+}
+
+// Err - returns the error of the completed call, nil while the call is in flight
+func (f *Future) Err() error {
+	select {
+	case <-f.done:
+		return f.err
+	default:
+		return nil
+	}
+}
+
+// Cancel - cancels the call, the remote handler is canceled too
+func (f *Future) Cancel() {
+	if f.complete(context.Canceled) {
+		_ = f.c.publishMsg(nats.NewMsg(cancelSubject(f.requestID)))
+	}
+}
+
+// complete - completes the call once, returns false if it is already completed
+func (f *Future) complete(err error) bool {
+	return f.resolve(reflect.Value{}, err)
+}
+
+// resolve - completes the call once setting the response, returns false if it is already completed
+func (f *Future) resolve(response reflect.Value, err error) (ok bool) {
+	f.once.Do(func() {
+		ok = true
+		f.c.replies.remove(f)
+		if response.IsValid() && !response.IsZero() {
+			reflect.ValueOf(f.response).Elem().Set(response.Elem())
+		}
+		f.err = err
+		if f.finish != nil {
+			f.finish(err)
+		}
+		close(f.done)
+		if f.callback != nil {
+			f.callback(err)
+		}
+	})
+
+	return ok
+}
+
+// receive - completes the call by the reply
+func (f *Future) receive(msg *nats.Msg) {
+	if len(msg.Data) == 0 && msg.Header.Get("Status") == "503" {
+		f.complete(convertErr(nats.ErrNoResponders))
+		return
+	}
+
+	var respDTO = newResponseDTO(reflect.TypeOf(f.response))
+	if err := f.c.codec.decode(msg.Data, respDTO.Addr().Interface()); err != nil {
+		f.complete(err)
+		return
+	}
+
+	var response = respDTO.FieldByName("Response")
+	if dto := respDTO.FieldByName("Error").Interface().(ErrorDTO); dto.Type != nil {
+		f.resolve(response, dto.reconstruct())
+		return
+	}
+
+	if !response.IsZero() {
+		if err := validateFields(response.Interface()); err != nil {
+			f.resolve(response, fmt.Errorf("invalid response: %w", err))
+			return
+		}
+	}
+
+	f.resolve(response, nil)
+}
+
+// replyMux - routes replies of asynchronous calls received by the single inbox subscription
+type replyMux struct {
+	mux     sync.Mutex
+	prefix  string
+	sub     *nats.Subscription
+	pending map[string]*Future
+}
+
+// add - subscribes to replies if not already subscribed and registers the future until the reply or the expiry,
+// returns its reply subject
+func (m *replyMux) add(c *conn, f *Future, timeout time.Duration, expire func()) (reply string, err error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	if m.sub == nil || !m.sub.IsValid() {
+		m.prefix = nats.NewInbox() + "."
+		if m.sub, err = c.subscribe(m.prefix+"*", m.receive); err != nil {
+			return "", err
+		}
+	}
+	m.pending[f.requestID] = f
+	f.timer = time.AfterFunc(timeout, expire)
+
+	return m.prefix + f.requestID, nil
+}
+
+// remove - deletes the completed future and stops its timer
+func (m *replyMux) remove(f *Future) {
+	m.mux.Lock()
+	delete(m.pending, f.requestID)
+	if f.timer != nil {
+		f.timer.Stop()
+	}
+	m.mux.Unlock()
+}
+
+// len - returns the number of futures waiting for replies
+func (m *replyMux) len() int {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	return len(m.pending)
+}
+
+// receive - completes the future of the reply
+func (m *replyMux) receive(msg *nats.Msg) {
+	var id = msg.Subject[strings.LastIndexByte(msg.Subject, '.')+1:]
+
+	m.mux.Lock()
+	var f = m.pending[id]
+	m.mux.Unlock()
+
+	if f != nil {
+		f.receive(msg)
+	}
+}
+
+// close - completes all in-flight futures with the error
+func (m *replyMux) close(err error) {
+	m.mux.Lock()
+	var pending = make([]*Future, 0, len(m.pending))
+	for _, f := range m.pending {
+		pending = append(pending, f)
+	}
+	m.mux.Unlock()
+
+	for _, f := range pending {
+		f.complete(err)
+	}
+}
+
+func newReplyMux() *replyMux {
+	return &replyMux{pending: make(map[string]*Future)}
+}
+
+// RequestAsync - a remote procedure call which does not block the caller, the response is decoded
+// into the response value when the future is completed
+//
+// replies of all asynchronous calls are received by the single inbox subscription, retries are not applied
+//
+// the future is completed by the deadline of the context or by the timeout of the client if there is no deadline
+// (WithAsyncTimeout), the context is not watched for cancellation, Future.Cancel cancels the call
+func (c *Client) RequestAsync(ctx context.Context, subject string, request, response interface{}) *Future {
+	return c.requestAsync(ctx, subject, request, response, nil)
+}
+
+// RequestAsyncFunc - RequestAsync calling the callback on completion, the callback is called by the goroutine
+// receiving replies and must not block
+func (c *Client) RequestAsyncFunc(ctx context.Context, subject string, request, response interface{},
+	callback func(err error)) *Future {
+	return c.requestAsync(ctx, subject, request, response, callback)
+}
+
+func (c *Client) requestAsync(ctx context.Context, subject string, request, response interface{},
+	callback func(err error)) *Future {
+	var (
+		start = time.Now()
+		f     = &Future{c: c, requestID: nuid.Next(), response: response, done: make(chan struct{}),
+			callback: callback}
+	)
+	f.finish = func(err error) {
+		c.metrics.ObserveRequest(subject, outcomeOf(err), time.Since(start))
+		c.logger(subject).Debugw("RequestAsync",
+			"subject", subject, "elapsed", time.Since(start).Seconds(),
+			"request", c.redact.value(subject, request), "response", c.redact.value(subject, response),
+			"error", err,
+		)
+	}
+
+	// validate request and response
+	if err := validateModel(request); err != nil {
+		f.complete(fmt.Errorf("invalid request: %w", err))
+		return f
+	}
+
+	if err := validateModel(response); err != nil {
+		f.complete(fmt.Errorf("invalid response: %w", err))
+		return f
+	}
+
+	if err := validateFields(request); err != nil {
+		f.complete(err)
+		return f
+	}
+
+	session, err := getSession(ctx, c.metadata)
+	if err != nil {
+		f.complete(err)
+		return f
+	}
+	session.RequestID = &f.requestID
+
+	_, span := c.tracing.send(ctx, subject, trace.SpanKindClient, &session)
+	var finish = f.finish
+	f.finish = func(err error) {
+		endSpan(span, err)
+		finish(err)
+	}
+
+	var reqDTO = newRequestDTO(reflect.TypeOf(request))
+	reqDTO.FieldByName("Session").Set(reflect.ValueOf(session))
+	reqDTO.FieldByName("Request").Set(reflect.ValueOf(request))
+
+	var msg = nats.NewMsg(subject)
+	if msg.Data, err = c.codec.encode(reqDTO.Interface()); err != nil {
+		f.complete(err)
+		return f
+	}
+
+	if err = ctx.Err(); err != nil {
+		f.complete(err)
+		return f
+	}
+
+	// the timer completes the call by the deadline instead of a goroutine watching the context
+	var timeout, expired = c.asyncTimeout, convertErr(nats.ErrTimeout)
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout, expired = time.Until(deadline), context.DeadlineExceeded
+	}
+	var expire = func() {
+		if ctx.Err() != nil {
+			expired = ctx.Err()
+		}
+		// the remote handler has the same deadline, it is canceled only by the timeout of the client
+		if f.complete(expired) && !errors.Is(expired, context.DeadlineExceeded) {
+			_ = c.publishMsg(nats.NewMsg(cancelSubject(f.requestID)))
+		}
+	}
+
+	if msg.Reply, err = c.replies.add(c.conn, f, timeout, expire); err != nil {
+		f.complete(convertErr(err))
+		return f
+	}
+
+	if err = c.publishMsg(msg); err != nil {
+		f.complete(convertErr(err))
+		return f
+	}
+
+	return f
+}
+
+// PendingAsync - returns the number of asynchronous calls waiting for replies
+func (c *Client) PendingAsync() int {
+	return c.replies.len()
+}
@@ -0,0 +1,250 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nuid"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// batchHeader - header of the message with the batch of requests
+	batchHeader = "Wcnats-Batch"
+	// defaultBatchConcurrency - number of items of the batch handled at the same time
+	defaultBatchConcurrency = 8
+)
+
+// RequestBatch - remote procedure calls of the handler with many requests sent in one message, the handler
+// is called for each request as for Request
+//
+// requests must be []*struct and responses must be *[]*struct, errs contains the error of each response
+// decoded from its ErrorDTO, err is the error of the batch itself
+func (c *Client) RequestBatch(ctx context.Context, subject string, requests, responses interface{}) (errs []error, err error) {
+	var (
+		start = time.Now()
+		items int
+	)
+	defer func() {
+		c.metrics.ObserveRequest(subject, outcomeOf(err), time.Since(start))
+		c.logger(subject).Debugw("RequestBatch",
+			"subject", subject, "elapsed", time.Since(start).Seconds(), "items", items,
+			"requests", c.redact.value(subject, requests), "responses", c.redact.value(subject, responses),
+			"error", err,
+		)
+	}()
+
+	// validate requests and responses
+	var reqType, respType = reflect.TypeOf(requests), reflect.TypeOf(responses)
+	if reqType == nil || reqType.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("invalid requests: value is not []*struct")
+	}
+
+	if err = validateModel(reflect.New(reqType.Elem()).Elem().Interface()); err != nil {
+		return nil, fmt.Errorf("invalid requests: %w", err)
+	}
+
+	if respType == nil || respType.Kind() != reflect.Ptr || respType.Elem().Kind() != reflect.Slice {
+		return nil, fmt.Errorf("invalid responses: value is not *[]*struct")
+	}
+
+	if err = validateModel(reflect.New(respType.Elem().Elem()).Elem().Interface()); err != nil {
+		return nil, fmt.Errorf("invalid responses: %w", err)
+	}
+
+	var reqs = reflect.ValueOf(requests)
+	if items = reqs.Len(); items == 0 {
+		return nil, nil
+	}
+
+	for i := 0; i < items; i++ {
+		if reqs.Index(i).IsNil() {
+			return nil, fmt.Errorf("invalid requests: item %d is nil", i)
+		}
+	}
+
+	if err = validateFields(requests); err != nil {
+		return nil, err
+	}
+
+	session, err := getSession(ctx, c.metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	var requestID = nuid.Next()
+	session.RequestID = &requestID
+
+	ctx, span := c.tracing.send(ctx, subject, trace.SpanKindClient, &session)
+	defer func() { endSpan(span, err) }()
+
+	// create a DTO in memory, the response is the array of the responses of items
+	var (
+		reqDTO   = newRequestDTO(reqType)
+		itemType = newResponseDTO(respType.Elem().Elem()).Type()
+		respDTO  = newResponseDTO(reflect.SliceOf(itemType))
+	)
+	reqDTO.FieldByName("Session").Set(reflect.ValueOf(session))
+	reqDTO.FieldByName("Request").Set(reqs)
+
+	var msg = nats.NewMsg(subject)
+	msg.Header.Set(batchHeader, fmt.Sprint(items))
+	if msg.Data, err = c.codec.encode(reqDTO.Interface()); err != nil {
+		return nil, err
+	}
+
+	// call
+	reply, err := c.requestMsg(ctx, msg)
+	if err != nil {
+		// stop the remote handlers, the results are no longer needed
+		if errors.Is(ctx.Err(), context.Canceled) {
+			_ = c.publishMsg(nats.NewMsg(cancelSubject(requestID)))
+		}
+
+		return nil, convertErr(err)
+	}
+
+	if err = c.codec.decode(reply.Data, respDTO.Addr().Interface()); err != nil {
+		return nil, err
+	}
+
+	// check error of the batch
+	if dto := respDTO.FieldByName("Error").Interface().(ErrorDTO); dto.Type != nil {
+		return nil, dto.reconstruct()
+	}
+
+	var (
+		results = respDTO.FieldByName("Response")
+		slice   = reflect.ValueOf(responses).Elem()
+	)
+	if results.Len() != items {
+		return nil, fmt.Errorf("invalid reply: %d responses for %d requests", results.Len(), items)
+	}
+
+	for i := 0; i < items; i++ {
+		var (
+			item = results.Index(i)
+			resp = item.FieldByName("Response")
+		)
+		if resp.IsNil() {
+			resp = reflect.New(respType.Elem().Elem().Elem())
+		}
+		slice = reflect.Append(slice, resp)
+
+		if dto := item.FieldByName("Error").Interface().(ErrorDTO); dto.Type != nil {
+			errs = append(errs, dto.reconstruct())
+		} else if vErr := validateFields(resp.Interface()); vErr != nil {
+			errs = append(errs, fmt.Errorf("invalid response: %w", vErr))
+		} else {
+			errs = append(errs, nil)
+		}
+	}
+	reflect.ValueOf(responses).Elem().Set(slice)
+
+	return errs, nil
+}
+
+// callBatch - implements the subscriber's call for the batch of requests, items are handled concurrently
+func (s *subscription) callBatch(msg *nats.Msg) {
+	var (
+		start    = time.Now()
+		reqDTO   = newRequestDTO(reflect.SliceOf(s.process.Type().In(1)))
+		itemType = newResponseDTO(s.process.Type().Out(0)).Type()
+		dtoValue = newResponseDTO(reflect.SliceOf(itemType))
+		session  SessionDTO
+		procErr  error
+		err      error
+	)
+	defer func() {
+		s.log.Debugw("CallBatch",
+			"subject", s.Subscription.Subject, "elapsed", time.Since(start).Seconds(),
+			"items", reqDTO.FieldByName("Request").Len(), "error", procErr, "reply error", err,
+		)
+	}()
+
+	s.begin(KindCall)
+	defer s.end(KindCall)
+
+	// decoding the message, the client receives the decoding error as the error of the batch
+	if procErr = s.codec.decode(msg.Data, reqDTO.Addr().Interface()); procErr == nil {
+		session = reqDTO.FieldByName("Session").Interface().(SessionDTO)
+
+		ctx, cancel := createSession(s.ctx, session, s.metadata)
+		defer cancel()
+
+		ctx, span := s.tracing.process(ctx, msg.Subject, trace.SpanKindServer, session)
+		defer func() { endSpan(span, procErr) }()
+
+		// the caller can cancel the batch while handlers are running
+		if session.RequestID != nil {
+			defer s.cancels.register(*session.RequestID, cancel)()
+		}
+
+		// the caller's deadline has already passed or the batch is canceled, the reply is not waited
+		if procErr = ctx.Err(); procErr != nil {
+			return
+		}
+
+		// the non-idempotent handler is called once for the batch
+		if procErr = s.dedup.check(session.RequestID); procErr == nil {
+			var (
+				info  = callInfo(ctx, msg.Subject, KindCall, session)
+				reqs  = reqDTO.FieldByName("Request")
+				items = reflect.MakeSlice(reflect.SliceOf(itemType), reqs.Len(), reqs.Len())
+				sem   = make(chan struct{}, s.batch)
+				wg    sync.WaitGroup
+			)
+			for i := 0; i < reqs.Len(); i++ {
+				sem <- struct{}{}
+				wg.Add(1)
+				go func(i int) {
+					defer func() { <-sem; wg.Done() }()
+					s.callItem(ctx, info, reqs.Index(i), items.Index(i))
+				}(i)
+			}
+			wg.Wait()
+
+			// the batch is canceled by the caller, the reply is suppressed
+			if errors.Is(ctx.Err(), context.Canceled) {
+				procErr = ctx.Err()
+				return
+			}
+
+			dtoValue.FieldByName("Response").Set(items)
+		}
+	}
+
+	// check error of the batch
+	if procErr != nil {
+		dtoValue.FieldByName("Error").Set(reflect.ValueOf(newErrorDTO(procErr)))
+	}
+
+	// reply to the client
+	err = s.response(msg.Reply, dtoValue.Addr().Interface())
+}
+
+// callItem - calls the handler for the item of the batch and sets its response and error
+func (s *subscription) callItem(ctx context.Context, info CallInfo, req reflect.Value, item reflect.Value) {
+	var (
+		start = time.Now()
+		err   error
+	)
+	defer func() {
+		s.metrics.ObserveHandler(s.Subscription.Subject, KindCall, handlerOutcome(err), time.Since(start))
+	}()
+
+	if req.IsNil() {
+		err = Errorf(CodeInvalidArgument, "request is nil")
+	} else if err = validateFields(req.Interface()); err == nil {
+		err = s.handle(ctx, info, req, item)
+	}
+
+	if err != nil {
+		item.FieldByName("Error").Set(reflect.ValueOf(newErrorDTO(err)))
+	}
+}
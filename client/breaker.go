@@ -0,0 +1,247 @@
+package client
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	defaultBreakerThreshold   = 5
+	defaultBreakerOpenTimeout = 10 * time.Second
+	// circuitIdle - time after which the unused circuit is forgotten, circuits are kept at least for the open timeout
+	circuitIdle = 10 * time.Minute
+)
+
+// CircuitState - state of the circuit breaker of the subject
+type CircuitState string
+
+const (
+	// StateClosed - calls are sent, failures are counted
+	StateClosed CircuitState = "closed"
+	// StateOpen - calls fail fast with *CircuitOpen until the open timeout passes
+	StateOpen CircuitState = "open"
+	// StateHalfOpen - trial calls are sent, their success closes the circuit and their failure opens it again
+	StateHalfOpen CircuitState = "half_open"
+)
+
+// BreakerPolicy - thresholds of the circuit breaker of the subject
+type BreakerPolicy struct {
+	// Thresholds - consecutive failures of the outcome opening the circuit, outcomes which are not listed
+	// are not failures, by default 5 of OutcomeTimeout and OutcomeNoResponders
+	Thresholds map[Outcome]int
+	// OpenTimeout - time the circuit is open before trial calls, by default 10 seconds
+	OpenTimeout time.Duration
+	// HalfOpenCalls - successful trial calls closing the circuit, at most the same number are sent at once,
+	// by default 1
+	HalfOpenCalls int
+}
+
+// CircuitOpen - the circuit of the subject is open, the call is not sent
+type CircuitOpen struct {
+	Subject    string
+	RetryAfter time.Duration
+}
+
+func (e *CircuitOpen) Error() string {
+	return fmt.Sprintf("circuit of %s is open, retry after %s", e.Subject, e.RetryAfter)
+}
+
+// StatusCode - returns CodeUnavailable
+func (e *CircuitOpen) StatusCode() Code {
+	return CodeUnavailable
+}
+
+func init() {
+	RegisterErrorType("wcnats.CircuitOpen", &CircuitOpen{})
+}
+
+// breakerRule - the policy of subjects matching the pattern
+type breakerRule struct {
+	pattern string
+	policy  BreakerPolicy
+}
+
+// breakers - circuits of subjects, each subject matching the pattern has its own circuit,
+// circuits of subjects unused for the idle time are deleted
+type breakers struct {
+	mux      sync.Mutex
+	rules    []breakerRule
+	circuits map[string]*circuit
+	swept    time.Time
+}
+
+// circuit - the state of the circuit breaker of the subject
+type circuit struct {
+	policy    BreakerPolicy
+	state     CircuitState
+	failures  map[Outcome]int
+	openedAt  time.Time
+	trials    int
+	successes int
+	used      time.Time
+}
+
+// withDefaults - returns the policy with default values of unset fields
+func (p BreakerPolicy) withDefaults() BreakerPolicy {
+	if p.Thresholds == nil {
+		p.Thresholds = map[Outcome]int{
+			OutcomeTimeout:      defaultBreakerThreshold,
+			OutcomeNoResponders: defaultBreakerThreshold,
+		}
+	}
+	if p.OpenTimeout <= 0 {
+		p.OpenTimeout = defaultBreakerOpenTimeout
+	}
+	if p.HalfOpenCalls <= 0 {
+		p.HalfOpenCalls = 1
+	}
+
+	return p
+}
+
+// set - sets the policy of the pattern
+func (b *breakers) set(pattern string, policy BreakerPolicy) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	for i := range b.rules {
+		if b.rules[i].pattern == pattern {
+			b.rules[i].policy = policy.withDefaults()
+			return
+		}
+	}
+	b.rules = append(b.rules, breakerRule{pattern: pattern, policy: policy.withDefaults()})
+}
+
+// circuit - returns the circuit of the subject, nil if the subject has no policy
+func (b *breakers) circuit(subject string, now time.Time) *circuit {
+	if c := b.circuits[subject]; c != nil {
+		c.used = now
+		return c
+	}
+
+	for _, r := range b.rules {
+		if matchSubject(r.pattern, subject) {
+			if b.circuits == nil {
+				b.circuits = make(map[string]*circuit)
+			}
+			b.sweep(now)
+
+			var c = &circuit{policy: r.policy, state: StateClosed, failures: make(map[Outcome]int), used: now}
+			b.circuits[subject] = c
+			return c
+		}
+	}
+
+	return nil
+}
+
+// sweep - deletes circuits unused for the idle time and the open timeout without trial calls in flight
+func (b *breakers) sweep(now time.Time) {
+	if now.Sub(b.swept) < circuitIdle {
+		return
+	}
+
+	for subject, c := range b.circuits {
+		if idle := now.Sub(c.used); idle > circuitIdle && idle > c.policy.OpenTimeout && c.trials == 0 {
+			delete(b.circuits, subject)
+		}
+	}
+	b.swept = now
+}
+
+// allow - checks the circuit of the subject before the call, done records the result of the allowed call,
+// onChange is called on changes of the state
+func (b *breakers) allow(subject string, onChange func(subject string, from, to CircuitState)) (
+	done func(err error), err error) {
+	var now = time.Now()
+
+	b.mux.Lock()
+	var c = b.circuit(subject, now)
+	if c == nil {
+		b.mux.Unlock()
+		return func(error) {}, nil
+	}
+
+	var from, trial = c.state, false
+	if c.state == StateOpen {
+		if elapsed := now.Sub(c.openedAt); elapsed < c.policy.OpenTimeout {
+			b.mux.Unlock()
+			return nil, &CircuitOpen{Subject: subject, RetryAfter: c.policy.OpenTimeout - elapsed}
+		}
+		c.state, c.trials, c.successes = StateHalfOpen, 0, 0
+	}
+
+	if c.state == StateHalfOpen {
+		if c.trials >= c.policy.HalfOpenCalls {
+			b.mux.Unlock()
+			return nil, &CircuitOpen{Subject: subject}
+		}
+		c.trials++
+		trial = true
+	}
+	var to = c.state
+	b.mux.Unlock()
+
+	if from != to && onChange != nil {
+		onChange(subject, from, to)
+	}
+
+	return func(err error) {
+		var now = time.Now()
+
+		b.mux.Lock()
+		var from = c.state
+		c.used = now
+		c.record(outcomeOf(err), trial, now)
+		var to = c.state
+		b.mux.Unlock()
+
+		if from != to && onChange != nil {
+			onChange(subject, from, to)
+		}
+	}, nil
+}
+
+// record - changes the state of the circuit by the outcome of the call
+func (c *circuit) record(outcome Outcome, trial bool, now time.Time) {
+	var threshold, failure = c.policy.Thresholds[outcome]
+
+	switch {
+	case trial:
+		c.trials--
+		if c.state != StateHalfOpen {
+			return
+		}
+		if failure {
+			c.open(now)
+		} else if outcome != OutcomeCanceled {
+			if c.successes++; c.successes >= c.policy.HalfOpenCalls {
+				c.state = StateClosed
+			}
+		}
+	case c.state != StateClosed, outcome == OutcomeCanceled:
+		// the call was sent before the circuit was opened or its result is unknown
+	case failure:
+		if c.failures[outcome]++; c.failures[outcome] >= threshold {
+			c.open(now)
+		}
+	default:
+		c.failures = make(map[Outcome]int)
+	}
+}
+
+// open - opens the circuit, failures are counted again after closing
+func (c *circuit) open(now time.Time) {
+	c.state, c.openedAt = StateOpen, now
+	c.failures = make(map[Outcome]int)
+}
+
+// circuitChanged - reports the change of the state of the circuit of the subject
+func (c *Client) circuitChanged(subject string, from, to CircuitState) {
+	c.logger(subject).Debugw("Circuit", "subject", subject, "from", from, "to", to)
+	if c.hooks.OnCircuitChange != nil {
+		c.hooks.OnCircuitChange(subject, from, to)
+	}
+}
@@ -0,0 +1,108 @@
+package client
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+const (
+	// cancelSubjectPrefix - prefix of subjects of the cancel signals, the request ID is the last token
+	cancelSubjectPrefix = "_WCNATS.CANCEL."
+	// cancelTombstoneTTL - how long the signal is kept if it came before the request
+	cancelTombstoneTTL = time.Minute
+)
+
+// cancelRegistry - cancels contexts of in-flight handlers by cancel signals of callers
+type cancelRegistry struct {
+	mux        sync.Mutex
+	sub        *nats.Subscription
+	inFlight   map[string][]*context.CancelFunc
+	tombstones map[string]time.Time
+}
+
+// listen - subscribes to cancel signals if not already subscribed
+func (r *cancelRegistry) listen(c *conn) (err error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if r.sub != nil && r.sub.IsValid() {
+		return nil
+	}
+
+	r.sub, err = c.subscribe(cancelSubjectPrefix+"*", r.receive)
+
+	return err
+}
+
+// receive - cancels the handler of the request from the signal
+func (r *cancelRegistry) receive(msg *nats.Msg) {
+	var id = strings.TrimPrefix(msg.Subject, cancelSubjectPrefix)
+
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	// all attempts of the request are canceled
+	if cancels, ok := r.inFlight[id]; ok {
+		for _, cancel := range cancels {
+			(*cancel)()
+		}
+		return
+	}
+
+	// the request is still in the queue of the subscription
+	var now = time.Now()
+	for k, exp := range r.tombstones {
+		if now.After(exp) {
+			delete(r.tombstones, k)
+		}
+	}
+	r.tombstones[id] = now.Add(cancelTombstoneTTL)
+}
+
+// register - stores the cancel function of the handler context, returns function for removal
+func (r *cancelRegistry) register(id string, cancel context.CancelFunc) (unregister func()) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if _, ok := r.tombstones[id]; ok {
+		delete(r.tombstones, id)
+		cancel()
+	}
+	var entry = &cancel
+	r.inFlight[id] = append(r.inFlight[id], entry)
+
+	return func() {
+		r.mux.Lock()
+		defer r.mux.Unlock()
+
+		var cancels = r.inFlight[id]
+		for i := range cancels {
+			if cancels[i] == entry {
+				cancels = append(cancels[:i], cancels[i+1:]...)
+				break
+			}
+		}
+
+		if len(cancels) == 0 {
+			delete(r.inFlight, id)
+		} else {
+			r.inFlight[id] = cancels
+		}
+	}
+}
+
+// cancelSubject - returns subject of the cancel signal for the request
+func cancelSubject(id string) string {
+	return cancelSubjectPrefix + id
+}
+
+func newCancelRegistry() *cancelRegistry {
+	return &cancelRegistry{
+		inFlight:   make(map[string][]*context.CancelFunc),
+		tombstones: make(map[string]time.Time),
+	}
+}
@@ -2,29 +2,58 @@ package client
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
+	"sync"
 	"time"
 
-	"go.uber.org/zap"
-
 	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nuid"
+	"go.opentelemetry.io/otel/trace"
 )
 
+const defaultGracePeriod = 5 * time.Second
+
 type Client struct {
 	*conn
-	log *zap.SugaredLogger
+	log                Logger
+	logRules           []*logRule
+	redact             *redactor
+	metadata           MetadataPolicy
+	cancels            *cancelRegistry
+	replies            *replyMux
+	asyncTimeout       time.Duration
+	tracing            tracing
+	metrics            Metrics
+	hooks              Hooks
+	retryRules         []retryRule
+	interceptors       []ClientInterceptor
+	serverInterceptors []ServerInterceptor
+	limits             *rateLimiter
+	serverLimits       *rateLimiter
+	breakers           *breakers
+	hedging            *hedging
+	ctx                context.Context
+	grace              time.Duration
+	subsMux            sync.Mutex
+	subs               map[*subscription]struct{}
 }
 
 // Request - a remote procedure call is created
 //
 // use handle: func(context.Context,*struct)(*struct,error)
 func (c *Client) Request(ctx context.Context, subject string, request, response interface{}) (err error) {
-	start := time.Now()
+	var (
+		start   = time.Now()
+		attempt int
+	)
 	defer func() {
-		c.log.Debugw("Request",
-			"subject", subject, "elapsed", time.Since(start).Seconds(),
-			"request", request, "response", response, "error", err,
+		c.metrics.ObserveRequest(subject, outcomeOf(err), time.Since(start))
+		c.logger(subject).Debugw("Request",
+			"subject", subject, "elapsed", time.Since(start).Seconds(), "attempts", attempt,
+			"request", c.redact.value(subject, request), "response", c.redact.value(subject, response),
+			"error", err,
 		)
 	}()
 
@@ -37,17 +66,75 @@ func (c *Client) Request(ctx context.Context, subject string, request, response
 		return fmt.Errorf("invalid response: %w", err)
 	}
 
-	// create a DTO in memory
-	var (
-		reqDTO  = newRequestDTO(reflect.TypeOf(request))
-		respDTO = newResponseDTO(reflect.TypeOf(response))
-	)
-	reqDTO.FieldByName("Session").Set(reflect.ValueOf(getSession(ctx)))
-	reqDTO.FieldByName("Request").Set(reflect.ValueOf(request))
+	var info = CallInfo{Subject: subject, Kind: KindCall, RequestID: nuid.Next(), Metadata: MetadataFrom(ctx)}
 
-	// call
-	if err = c.request(ctx, subject, reqDTO.Interface(), respDTO.Addr().Interface()); err != nil {
-		return convertErr(err)
+	return c.intercept(ctx, info, request, response, func(ctx context.Context, request, response interface{}) error {
+		return c.call(ctx, subject, info.RequestID, request, response, &attempt)
+	})
+}
+
+// call - sends the call with retries, the request ID is the same for all attempts
+func (c *Client) call(ctx context.Context, subject, requestID string, request, response interface{},
+	attempts *int) (err error) {
+	if err = validateFields(request); err != nil {
+		return err
+	}
+
+	session, err := getSession(ctx, c.metadata)
+	if err != nil {
+		return err
+	}
+	session.RequestID = &requestID
+
+	if err = c.limits.take(ctx, subject); err != nil {
+		return err
+	}
+
+	ctx, span := c.tracing.send(ctx, subject, trace.SpanKindClient, &session)
+	defer func() { endSpan(span, err) }()
+
+	// calling with retries of the policy of the subject, retries have the same request ID
+	var policy = c.retryPolicy(subject)
+	for attempt := 1; ; attempt++ {
+		*attempts = attempt
+		if err = c.attempt(ctx, subject, policy, attempt, session, request, response); err == nil ||
+			!policy.retry(ctx, attempt, err) {
+			return err
+		}
+
+		if m, ok := c.metrics.(RetryMetrics); ok {
+			m.ObserveRetry(subject, attempt, outcomeOf(err))
+		}
+		c.logger(subject).Debugw("Retry", "subject", subject, "attempt", attempt, "error", err)
+	}
+}
+
+// attempt - makes one attempt of the call, the attempt is not sent while the circuit of the subject is open
+func (c *Client) attempt(ctx context.Context, subject string, policy RetryPolicy, attempt int, session SessionDTO,
+	request, response interface{}) (err error) {
+	done, err := c.breakers.allow(subject, c.circuitChanged)
+	if err != nil {
+		return err
+	}
+	defer func() { done(err) }()
+
+	attemptCtx, cancel := policy.attemptContext(ctx, attempt)
+	defer cancel()
+
+	session.Attempt = attempt
+	if deadline, ok := attemptCtx.Deadline(); ok {
+		session.Deadline = &deadline
+	}
+
+	// call, hedged calls send copies while there is no reply
+	respDTO, err := c.exchange(attemptCtx, subject, session, request, reflect.TypeOf(response))
+	if err != nil {
+		// stop the remote handler, the result is no longer needed
+		if errors.Is(ctx.Err(), context.Canceled) {
+			_ = c.publishMsg(nats.NewMsg(cancelSubject(*session.RequestID)))
+		}
+
+		return attemptErr(ctx, attemptCtx, err)
 	}
 
 	// create response
@@ -57,8 +144,12 @@ func (c *Client) Request(ctx context.Context, subject string, request, response
 	}
 
 	// check error
-	if respDTO.FieldByName("Error").Interface().(ErrorDTO).Type != nil {
-		return respDTO.FieldByName("Error").Interface().(ErrorDTO)
+	if dto := respDTO.FieldByName("Error").Interface().(ErrorDTO); dto.Type != nil {
+		return dto.reconstruct()
+	}
+
+	if err := validateFields(response); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
 	}
 
 	return nil
@@ -70,8 +161,9 @@ func (c *Client) Request(ctx context.Context, subject string, request, response
 func (c *Client) Publish(ctx context.Context, subject string, value interface{}) (err error) {
 	var start = time.Now()
 	defer func() {
-		c.log.Debugw("Publish", "subject", subject, "elapsed", time.Since(start).Seconds(),
-			"value", value, "error", err,
+		c.metrics.ObservePublish(subject, outcomeOf(err))
+		c.logger(subject).Debugw("Publish", "subject", subject, "elapsed", time.Since(start).Seconds(),
+			"value", c.redact.value(subject, value), "error", err,
 		)
 	}()
 
@@ -79,9 +171,37 @@ func (c *Client) Publish(ctx context.Context, subject string, value interface{})
 		return fmt.Errorf("invalid value: %w", err)
 	}
 
+	var info = CallInfo{Subject: subject, Kind: KindNotify, Metadata: MetadataFrom(ctx)}
+
+	return c.intercept(ctx, info, value, nil, func(ctx context.Context, value, _ interface{}) error {
+		return c.notify(ctx, subject, value)
+	})
+}
+
+// notify - sends the notification
+func (c *Client) notify(ctx context.Context, subject string, value interface{}) (err error) {
+	if err = validateFields(value); err != nil {
+		return err
+	}
+
+	session, err := getSession(ctx, c.metadata)
+	if err != nil {
+		return err
+	}
+
+	if err = c.limits.take(ctx, subject); err != nil {
+		return err
+	}
+
+	// the notification is not bound to the publisher's deadline, nobody waits for its result
+	session.Deadline = nil
+
+	_, span := c.tracing.send(ctx, subject, trace.SpanKindProducer, &session)
+	defer func() { endSpan(span, err) }()
+
 	// create a DTO in memory
 	var reqDTO = newRequestDTO(reflect.TypeOf(value))
-	reqDTO.FieldByName("Session").Set(reflect.ValueOf(getSession(ctx)))
+	reqDTO.FieldByName("Session").Set(reflect.ValueOf(session))
 	reqDTO.FieldByName("Request").Set(reflect.ValueOf(value))
 
 	return c.conn.publish(subject, reqDTO.Interface())
@@ -90,15 +210,19 @@ func (c *Client) Publish(ctx context.Context, subject string, value interface{})
 // Subscribe - subscribe handle for remote call or notify
 //
 // use handle:
+//
 //	for rpc:    func(context.Context,*struct)(*struct,error)
 //	for notify: func(context.Context,*struct)(error)
-func (c *Client) Subscribe(subject string, handle interface{}) (Subscription, error) {
+//
+// handler contexts are derived from the context of the subscription and are canceled
+// after the grace period of unsubscribing, draining or closing
+func (c *Client) Subscribe(subject string, handle interface{}, opts ...SubscribeOption) (Subscription, error) {
 	var (
 		start = time.Now()
 		err   error
 	)
 	defer func() {
-		c.log.Debugw("Subscribe", "subject", subject, "elapsed", time.Since(start).Seconds(),
+		c.logger(subject).Debugw("Subscribe", "subject", subject, "elapsed", time.Since(start).Seconds(),
 			"handle", reflect.TypeOf(handle).String(), "error", err,
 		)
 	}()
@@ -108,63 +232,192 @@ func (c *Client) Subscribe(subject string, handle interface{}) (Subscription, er
 		return nil, err
 	}
 
-	var (
-		sub = &subscription{
-			log:          c.log,
-			Subscription: nil,
-			process:      reflect.ValueOf(handle),
-			response:     reflect.ValueOf(c.publish),
-		}
-		reqDTO           = newRequestDTO(reflect.TypeOf(handle).In(1))
-		typeOfDtoReqIn   = []reflect.Type{reflect.TypeOf(""), reflect.TypeOf(""), reqDTO.Type()}
-		typeDtoReqHandle = reflect.FuncOf(typeOfDtoReqIn, nil, false)
-	)
+	var sub = &subscription{
+		log:          c.logger(subject),
+		redact:       c.redact,
+		Subscription: nil,
+		codec:        c.codec,
+		metadata:     c.metadata,
+		cancels:      c.cancels,
+		tracing:      c.tracing,
+		metrics:      c.metrics,
+		hooks:        c.hooks,
+		process:      reflect.ValueOf(handle),
+		response:     c.publish,
+		ctx:          c.ctx,
+		grace:        c.grace,
+		batch:        defaultBatchConcurrency,
+		interceptors: append([]ServerInterceptor(nil), c.serverInterceptors...),
+		limits:       c.serverLimits,
+	}
+	for _, opt := range opts {
+		opt(sub)
+	}
 
-	var valueDtoReqHandle reflect.Value
+	if sub.queued && !validQueueName(sub.queue) {
+		err = convertErr(nats.ErrBadQueueName)
+		return nil, err
+	}
+
+	var cb nats.MsgHandler
 	if isRequest {
 		if err = validateHandleOfCall(handle); err != nil {
 			return nil, fmt.Errorf("invalid handle: %w", err)
 		}
-		valueDtoReqHandle = reflect.MakeFunc(typeDtoReqHandle, sub.call)
+		if err = c.cancels.listen(c.conn); err != nil {
+			return nil, convertErr(err)
+		}
+		cb = sub.call
 	} else {
 		if err = validateHandleOfNotify(handle); err != nil {
 			return nil, fmt.Errorf("invalid handle: %w", err)
 		}
-		valueDtoReqHandle = reflect.MakeFunc(typeDtoReqHandle, sub.notify)
+		cb = sub.notify
 	}
 
-	sub.Subscription, err = c.subscribe(subject, valueDtoReqHandle.Interface())
+	sub.ctx, sub.cancel = context.WithCancel(sub.ctx)
+	sub.exec.start(sub.ctx)
+
+	// messages can be delivered before the subscription is assigned, handlers wait for it
+	var ready = make(chan struct{})
+	var handler = func(msg *nats.Msg) {
+		<-ready
+		sub.dispatch(msg, cb)
+	}
+	if sub.queued {
+		sub.Subscription, err = c.queueSubscribe(subject, sub.queue, handler)
+	} else {
+		sub.Subscription, err = c.subscribe(subject, handler)
+	}
+	close(ready)
 	if err != nil {
+		sub.cancel()
 		return nil, convertErr(err)
 	}
 
+	c.subsMux.Lock()
+	c.subs[sub] = struct{}{}
+	c.subsMux.Unlock()
+
 	return sub, nil
 }
 
-// Unsubscribe - delete subscription
+// QueueSubscribe - subscribe handle to the queue group, each message is delivered to one member of the group
+//
+// use handle as for Subscribe
+func (c *Client) QueueSubscribe(subject, queue string, handle interface{}, opts ...SubscribeOption) (Subscription, error) {
+	return c.Subscribe(subject, handle, append(opts, SubQueue(queue))...)
+}
+
+// Unsubscribe - delete subscription, in-flight handlers are canceled after the grace period
 func (c *Client) Unsubscribe(sub Subscription) (err error) {
+	if sub == nil {
+		return errors.New("invalid subscription: nil")
+	}
+
 	var (
 		start  = time.Now()
 		handle interface{}
 	)
 	defer func() {
-		c.log.Debugw("Unsubscribe",
+		c.logger(sub.GetSubject()).Debugw("Unsubscribe",
 			"subject", sub.GetSubject(), "elapsed", time.Since(start).Seconds(),
 			"handle", handle, "error", err,
 		)
 	}()
 
 	if s, ok := sub.(*subscription); ok {
+		c.release(s)
 		return convertErr(s.Subscription.Unsubscribe())
 	}
 
 	return fmt.Errorf("invalid subscription type %s", reflect.TypeOf(sub).String())
 }
 
+// Drain - delete subscription after processing of pending messages,
+// in-flight handlers are canceled after the grace period
+func (c *Client) Drain(sub Subscription) (err error) {
+	if sub == nil {
+		return errors.New("invalid subscription: nil")
+	}
+
+	var start = time.Now()
+	defer func() {
+		c.logger(sub.GetSubject()).Debugw("Drain",
+			"subject", sub.GetSubject(), "elapsed", time.Since(start).Seconds(), "error", err,
+		)
+	}()
+
+	if s, ok := sub.(*subscription); ok {
+		c.release(s)
+		return convertErr(s.Subscription.Drain())
+	}
+
+	return fmt.Errorf("invalid subscription type %s", reflect.TypeOf(sub).String())
+}
+
+// Close - deletes all subscriptions, waits for in-flight handlers during the grace period,
+// cancels the remaining ones and closes connection
+func (c *Client) Close() {
+	c.subsMux.Lock()
+	var subs = c.subs
+	c.subs = make(map[*subscription]struct{})
+	c.subsMux.Unlock()
+
+	for s := range subs {
+		if err := s.Subscription.Unsubscribe(); err != nil {
+			c.logger(s.GetSubject()).Debugw("Close", "subject", s.GetSubject(), "error", err)
+		}
+	}
+
+	var deadline = time.Now().Add(c.grace)
+	for s := range subs {
+		s.shutdown(deadline)
+	}
+
+	c.replies.close(convertErr(nats.ErrConnectionClosed))
+	c.conn.Close()
+}
+
+// release - forgets the subscription and stops its handlers after the grace period
+func (c *Client) release(s *subscription) {
+	c.subsMux.Lock()
+	delete(c.subs, s)
+	c.subsMux.Unlock()
+
+	s.stop()
+}
+
 // New - return new 'NATS' client for rpc and broadcast notifications
-func New(log *zap.SugaredLogger, url, name string, maxReconnects int) *Client {
-	return &Client{
-		conn: newConn(url, nats.JSON_ENCODER, name, maxReconnects),
-		log:  log,
+//
+// log may be *zap.SugaredLogger, an adapter of the logger package or nil to discard entries
+func New(log Logger, url, name string, maxReconnects int, opts ...Option) *Client {
+	if log == nil {
+		log = NopLogger()
+	}
+
+	var c = &Client{
+		conn:         newConn(url, jsonCodec{}, name, maxReconnects),
+		log:          log,
+		metadata:     defaultMetadataPolicy(),
+		cancels:      newCancelRegistry(),
+		replies:      newReplyMux(),
+		asyncTimeout: defaultAsyncTimeout,
+		tracing:      newTracing(nil, nil),
+		metrics:      nopMetrics{},
+		redact:       &redactor{},
+		limits:       &rateLimiter{},
+		serverLimits: &rateLimiter{},
+		breakers:     &breakers{},
+		hedging:      &hedging{},
+		ctx:          context.Background(),
+		grace:        defaultGracePeriod,
+		subs:         make(map[*subscription]struct{}),
 	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
 }
@@ -0,0 +1,23 @@
+package client
+
+import (
+	"encoding/json"
+)
+
+// codec - converts transport structures to message data and back
+type codec interface {
+	encode(v interface{}) ([]byte, error)
+	decode(data []byte, vPtr interface{}) error
+}
+
+type jsonCodec struct{}
+
+// encode - returns JSON representation of the value
+func (jsonCodec) encode(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// decode - fills the value by pointer from JSON data
+func (jsonCodec) decode(data []byte, vPtr interface{}) error {
+	return json.Unmarshal(data, vPtr)
+}
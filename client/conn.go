@@ -9,9 +9,9 @@ import (
 
 type conn struct {
 	mux      sync.Mutex
-	conn     *nats.EncodedConn
+	conn     *nats.Conn
+	codec    codec
 	url      string
-	encType  string
 	name     string
 	maxRecon int
 }
@@ -30,14 +30,23 @@ func (c *conn) Close() {
 }
 
 func (c *conn) publish(sub string, v interface{}) (err error) {
+	msg := nats.NewMsg(sub)
+	if msg.Data, err = c.codec.encode(v); err != nil {
+		return err
+	}
+
+	return c.publishMsg(msg)
+}
+
+func (c *conn) publishMsg(msg *nats.Msg) (err error) {
 	if err = c.connect(); err != nil {
 		return err
 	}
 
-	return c.conn.Publish(sub, v)
+	return c.conn.PublishMsg(msg)
 }
 
-func (c *conn) subscribe(sub string, cb nats.Handler) (s *nats.Subscription, err error) {
+func (c *conn) subscribe(sub string, cb nats.MsgHandler) (s *nats.Subscription, err error) {
 	if err = c.connect(); err != nil {
 		return nil, err
 	}
@@ -45,38 +54,62 @@ func (c *conn) subscribe(sub string, cb nats.Handler) (s *nats.Subscription, err
 	return c.conn.Subscribe(sub, cb)
 }
 
-func (c *conn) request(ctx context.Context, sub string, v interface{}, vPtr interface{}) (err error) {
+func (c *conn) subscribeSync(sub string) (s *nats.Subscription, err error) {
 	if err = c.connect(); err != nil {
-		return err
+		return nil, err
 	}
 
-	return c.conn.RequestWithContext(ctx, sub, v, vPtr)
+	return c.conn.SubscribeSync(sub)
 }
 
-func (c *conn) connect() error {
-	c.mux.Lock()
-	if c.conn != nil {
-		c.mux.Unlock()
-		return nil
+func (c *conn) queueSubscribe(sub, queue string, cb nats.MsgHandler) (s *nats.Subscription, err error) {
+	if err = c.connect(); err != nil {
+		return nil, err
+	}
+
+	return c.conn.QueueSubscribe(sub, queue, cb)
+}
+
+func (c *conn) request(ctx context.Context, sub string, v interface{}, vPtr interface{}) (err error) {
+	msg := nats.NewMsg(sub)
+	if msg.Data, err = c.codec.encode(v); err != nil {
+		return err
 	}
 
-	sc, err := nats.Connect(c.url, nats.Name(c.name), nats.MaxReconnects(c.maxRecon))
+	resp, err := c.requestMsg(ctx, msg)
 	if err != nil {
-		c.mux.Unlock()
 		return err
 	}
 
-	c.conn, err = nats.NewEncodedConn(sc, c.encType)
-	c.mux.Unlock()
+	return c.codec.decode(resp.Data, vPtr)
+}
+
+func (c *conn) requestMsg(ctx context.Context, msg *nats.Msg) (resp *nats.Msg, err error) {
+	if err = c.connect(); err != nil {
+		return nil, err
+	}
+
+	return c.conn.RequestMsgWithContext(ctx, msg)
+}
+
+func (c *conn) connect() (err error) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	if c.conn != nil {
+		return nil
+	}
+
+	c.conn, err = nats.Connect(c.url, nats.Name(c.name), nats.MaxReconnects(c.maxRecon))
 
 	return err
 }
 
 // newConn - creates connector for auto connecting
-func newConn(url, encType, name string, maxReconnects int) *conn {
+func newConn(url string, cd codec, name string, maxReconnects int) *conn {
 	return &conn{
 		url:      url,
-		encType:  encType,
+		codec:    cd,
 		name:     name,
 		maxRecon: maxReconnects,
 		conn:     nil,
@@ -2,25 +2,35 @@ package client
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"reflect"
-)
-
-const (
-	contextKeySession = "session"
-	contextKeyService = "service"
-	contextKeyMethod  = "method"
+	"time"
 )
 
 type SessionDTO struct {
-	Session *string
-	Service *string
-	Method  *string
+	Session   *string
+	Service   *string
+	Method    *string
+	Metadata  Metadata
+	Deadline  *time.Time
+	RequestID *string
+	Attempt   int
+	Hedge     int
+	Trace     map[string]string
 }
 
+// ErrorDTO - error of the handler transferred to the caller
+//
+// Type is the registered name of the error or its Go type, the registered error is reconstructed
+// by the client and is available with errors.Is and errors.As
 type ErrorDTO struct {
 	Type    *string
 	Message *string
+	Code    *Code
+	Details json.RawMessage
+	err     error
 }
 
 func (e ErrorDTO) Error() string {
@@ -31,6 +41,56 @@ func (e ErrorDTO) Error() string {
 	return fmt.Sprintf("%s", *e.Message)
 }
 
+// StatusCode - returns the status code of the handler error
+func (e ErrorDTO) StatusCode() Code {
+	if e.Code == nil {
+		return CodeUnknown
+	}
+
+	return *e.Code
+}
+
+// Unwrap - returns the reconstructed registered error
+func (e ErrorDTO) Unwrap() error {
+	return e.err
+}
+
+// newErrorDTO - creates a transport structure of the process error
+//
+// the error of the downstream call is forwarded with its type, code and details
+func newErrorDTO(err error) ErrorDTO {
+	var (
+		m    = err.Error()
+		code = CodeOf(err)
+		dto  ErrorDTO
+	)
+
+	if errors.As(err, &dto) && dto.Type != nil {
+		dto.Message, dto.err = &m, nil
+		return dto
+	}
+
+	var t string
+	if name, details, ok := encodeRegistered(err); ok {
+		t, dto.Details = name, details
+	} else {
+		t = reflect.TypeOf(err).String()
+	}
+
+	dto.Type, dto.Message, dto.Code = &t, &m, &code
+
+	return dto
+}
+
+// reconstruct - restores the registered error of the handler
+func (e ErrorDTO) reconstruct() ErrorDTO {
+	if e.Type != nil {
+		e.err = decodeRegistered(*e.Type, e.Details)
+	}
+
+	return e
+}
+
 // newRequestDTO - creates a transport data structure in memory to receive a request
 func newRequestDTO(req reflect.Type) reflect.Value {
 	//
@@ -136,43 +196,69 @@ func newResponseDTO(resp reflect.Type) reflect.Value {
 	return dto.Elem().Field(0)
 }
 
-// getSession - return session context keys
-func getSession(ctx context.Context) (dto SessionDTO) {
+// getSession - return session of context for transfer
+func getSession(ctx context.Context, policy MetadataPolicy) (dto SessionDTO, err error) {
+	var s = SessionFrom(ctx)
+	if s.ID != "" {
+		dto.Session = &s.ID
+	}
+
+	if s.Service != "" {
+		dto.Service = &s.Service
+	}
+
+	if s.Method != "" {
+		dto.Method = &s.Method
+	}
+
 	if ctx != nil {
-		var v interface{}
-		if v = ctx.Value(contextKeySession); v != nil {
-			if s, ok := v.(string); ok {
-				dto.Session = &s
-			}
+		if deadline, ok := ctx.Deadline(); ok {
+			dto.Deadline = &deadline
 		}
-		if v = ctx.Value(contextKeyService); v != nil {
-			if s, ok := v.(string); ok {
-				dto.Service = &s
-			}
-		}
-		if v = ctx.Value(contextKeyMethod); v != nil {
-			if s, ok := v.(string); ok {
-				dto.Method = &s
-			}
+	}
+
+	if md := MetadataFrom(ctx); len(md) != 0 {
+		if dto.Metadata, err = policy.validate(md); err != nil {
+			return dto, fmt.Errorf("invalid metadata: %w", err)
 		}
 	}
 
 	return
 }
 
-// createSession - create context with value if DTO's data exists, or empty context
-func createSession(dto SessionDTO) (ctx context.Context) {
-	ctx = context.Background()
+// createSession - create context of the parent with value if DTO's data exists
+//
+// if the caller sent the deadline, the context is done at the same time as the caller's context
+func createSession(parent context.Context, dto SessionDTO, policy MetadataPolicy) (ctx context.Context, cancel context.CancelFunc) {
+	ctx = parent
 	if dto.Session != nil {
-		ctx = context.WithValue(ctx, contextKeySession, *dto.Session)
+		ctx = WithSession(ctx, *dto.Session)
+	}
+
+	if dto.Service != nil || dto.Method != nil {
+		var service, method string
+		if dto.Service != nil {
+			service = *dto.Service
+		}
+		if dto.Method != nil {
+			method = *dto.Method
+		}
+		ctx = WithCaller(ctx, service, method)
+	}
+
+	if dto.RequestID != nil {
+		ctx = context.WithValue(ctx, contextKeyCall, received{requestID: *dto.RequestID, attempt: dto.Attempt,
+			hedge: dto.Hedge})
 	}
 
-	if dto.Service != nil {
-		ctx = context.WithValue(ctx, contextKeyService, *dto.Service)
+	if md := policy.filter(dto.Metadata); len(md) != 0 {
+		ctx = context.WithValue(ctx, contextKeyMetadata, md)
 	}
 
-	if dto.Method != nil {
-		ctx = context.WithValue(ctx, contextKeyMethod, *dto.Method)
+	if dto.Deadline != nil {
+		ctx, cancel = context.WithDeadline(ctx, *dto.Deadline)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
 	}
 
 	return
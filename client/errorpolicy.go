@@ -0,0 +1,80 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"time"
+)
+
+// ErrorPolicy - handling of errors of the notify handler
+type ErrorPolicy struct {
+	// Retries - number of in-process retries of the failed handler, the subscription waits during retries
+	Retries int
+	// Backoff - delay before the first retry, doubled for each next retry
+	Backoff time.Duration
+	// MaxBackoff - limit of the delay between retries, 0 - without limit
+	MaxBackoff time.Duration
+	// OnError - called when the notification failed after all retries
+	OnError func(ctx context.Context, subject string, request interface{}, err error)
+	// DeadLetter - subject where the failed notification is republished as *DeadLetter
+	DeadLetter string
+}
+
+// DeadLetter - the failed notification republished to the dead-letter subject
+//
+// subscribe with handle: func(context.Context,*client.DeadLetter)(error)
+type DeadLetter struct {
+	// Subject - subject of the original notification
+	Subject string
+	// Envelope - the original transport structure of the notification
+	Envelope json.RawMessage
+	// Error - the last error of the handler
+	Error ErrorDTO
+	// Attempts - number of calls of the handler
+	Attempts int
+	// FailedAt - time of the last failure
+	FailedAt time.Time
+}
+
+// retry - waits the backoff of the attempt, returns false if the attempt should not be retried
+func (p ErrorPolicy) retry(ctx context.Context, attempt int) bool {
+	if attempt > p.Retries {
+		return false
+	}
+
+	return sleep(ctx, backoff(p.Backoff, p.MaxBackoff, attempt))
+}
+
+// fail - reports the failed notification to the callback and republishes it to the dead-letter subject
+func (s *subscription) fail(ctx context.Context, subject string, envelope []byte, session SessionDTO,
+	request interface{}, err error, attempts int) {
+	if s.errPolicy.OnError != nil {
+		s.errPolicy.OnError(ctx, subject, request, err)
+	}
+
+	if s.errPolicy.DeadLetter == "" {
+		return
+	}
+
+	var dl = &DeadLetter{
+		Subject:  subject,
+		Error:    newErrorDTO(err),
+		Attempts: attempts,
+		FailedAt: time.Now(),
+	}
+	if json.Valid(envelope) {
+		dl.Envelope = envelope
+	}
+
+	// the dead letter is kept until it is handled
+	session.Deadline = nil
+
+	var dto = newRequestDTO(reflect.TypeOf(dl))
+	dto.FieldByName("Session").Set(reflect.ValueOf(session))
+	dto.FieldByName("Request").Set(reflect.ValueOf(dl))
+
+	if pErr := s.response(s.errPolicy.DeadLetter, dto.Interface()); pErr != nil {
+		s.log.Debugw("DeadLetter", "subject", subject, "dead letter", s.errPolicy.DeadLetter, "error", pErr)
+	}
+}
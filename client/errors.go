@@ -1,19 +1,38 @@
 package client
 
 import (
+	"errors"
 	"strings"
 
 	"github.com/nats-io/nats.go"
 )
 
+// data - common part of NATS errors, keeps the original error
 type data struct {
-	m string
+	err       error
+	m         string
+	temporary bool
 }
 
 func (e data) Error() string {
 	return e.m
 }
 
+// Unwrap - returns the original error of NATS, errors.Is works with NATS sentinel errors
+func (e data) Unwrap() error {
+	return e.err
+}
+
+// Temporary - checks that the error is transient and the operation may succeed later
+func (e data) Temporary() bool {
+	return e.temporary
+}
+
+// IsRetryable - checks that the operation may be retried
+func (e data) IsRetryable() bool {
+	return e.temporary
+}
+
 type ConnectionClosed struct {
 	data //  "nats: connection closed"
 }
@@ -298,157 +317,108 @@ type MsgNotFound struct {
 	data //  "nats: message not found"
 }
 
+// natsErrors - mapping of NATS sentinel errors to error types of the client
+var natsErrors = []struct {
+	target    error
+	temporary bool
+	wrap      func(d data) error
+}{
+	{target: nats.ErrConnectionClosed, temporary: false, wrap: func(d data) error { return ConnectionClosed{d} }},
+	{target: nats.ErrConnectionDraining, temporary: false, wrap: func(d data) error { return ConnectionDraining{d} }},
+	{target: nats.ErrDrainTimeout, temporary: false, wrap: func(d data) error { return DrainTimeout{d} }},
+	{target: nats.ErrConnectionReconnecting, temporary: true, wrap: func(d data) error { return ConnectionReconnecting{d} }},
+	{target: nats.ErrSecureConnRequired, temporary: false, wrap: func(d data) error { return SecureConnRequired{d} }},
+	{target: nats.ErrSecureConnWanted, temporary: false, wrap: func(d data) error { return SecureConnWanted{d} }},
+	{target: nats.ErrBadSubscription, temporary: false, wrap: func(d data) error { return BadSubscription{d} }},
+	{target: nats.ErrTypeSubscription, temporary: false, wrap: func(d data) error { return TypeSubscription{d} }},
+	{target: nats.ErrBadSubject, temporary: false, wrap: func(d data) error { return BadSubject{d} }},
+	{target: nats.ErrBadQueueName, temporary: false, wrap: func(d data) error { return BadQueueName{d} }},
+	{target: nats.ErrSlowConsumer, temporary: true, wrap: func(d data) error { return SlowConsumer{d} }},
+	{target: nats.ErrTimeout, temporary: true, wrap: func(d data) error { return Timeout{d} }},
+	{target: nats.ErrBadTimeout, temporary: false, wrap: func(d data) error { return BadTimeout{d} }},
+	{target: nats.ErrAuthorization, temporary: false, wrap: func(d data) error { return Authorization{d} }},
+	{target: nats.ErrAuthExpired, temporary: false, wrap: func(d data) error { return AuthExpired{d} }},
+	{target: nats.ErrAuthRevoked, temporary: false, wrap: func(d data) error { return AuthRevoked{d} }},
+	{target: nats.ErrAccountAuthExpired, temporary: false, wrap: func(d data) error { return AccountAuthExpired{d} }},
+	{target: nats.ErrNoServers, temporary: true, wrap: func(d data) error { return NoServers{d} }},
+	{target: nats.ErrJsonParse, temporary: false, wrap: func(d data) error { return JsonParse{d} }},
+	{target: nats.ErrChanArg, temporary: false, wrap: func(d data) error { return ChanArg{d} }},
+	{target: nats.ErrMaxPayload, temporary: false, wrap: func(d data) error { return MaxPayload{d} }},
+	{target: nats.ErrMaxMessages, temporary: false, wrap: func(d data) error { return MaxMessages{d} }},
+	{target: nats.ErrSyncSubRequired, temporary: false, wrap: func(d data) error { return SyncSubRequired{d} }},
+	{target: nats.ErrMultipleTLSConfigs, temporary: false, wrap: func(d data) error { return MultipleTLSConfigs{d} }},
+	{target: nats.ErrNoInfoReceived, temporary: false, wrap: func(d data) error { return NoInfoReceived{d} }},
+	{target: nats.ErrReconnectBufExceeded, temporary: true, wrap: func(d data) error { return ReconnectBufExceeded{d} }},
+	{target: nats.ErrInvalidConnection, temporary: false, wrap: func(d data) error { return InvalidConnection{d} }},
+	{target: nats.ErrInvalidMsg, temporary: false, wrap: func(d data) error { return InvalidMsg{d} }},
+	{target: nats.ErrInvalidArg, temporary: false, wrap: func(d data) error { return InvalidArg{d} }},
+	{target: nats.ErrInvalidContext, temporary: false, wrap: func(d data) error { return InvalidContext{d} }},
+	{target: nats.ErrNoDeadlineContext, temporary: false, wrap: func(d data) error { return NoDeadlineContext{d} }},
+	{target: nats.ErrNoEchoNotSupported, temporary: false, wrap: func(d data) error { return NoEchoNotSupported{d} }},
+	{target: nats.ErrClientIDNotSupported, temporary: false, wrap: func(d data) error { return ClientIDNotSupported{d} }},
+	{target: nats.ErrUserButNoSigCB, temporary: false, wrap: func(d data) error { return UserButNoSigCB{d} }},
+	{target: nats.ErrNkeyButNoSigCB, temporary: false, wrap: func(d data) error { return NkeyButNoSigCB{d} }},
+	{target: nats.ErrNoUserCB, temporary: false, wrap: func(d data) error { return NoUserCB{d} }},
+	{target: nats.ErrNkeyAndUser, temporary: false, wrap: func(d data) error { return NkeyAndUser{d} }},
+	{target: nats.ErrNkeysNotSupported, temporary: false, wrap: func(d data) error { return NkeysNotSupported{d} }},
+	{target: nats.ErrStaleConnection, temporary: true, wrap: func(d data) error { return StaleConnection{d} }},
+	{target: nats.ErrTokenAlreadySet, temporary: false, wrap: func(d data) error { return TokenAlreadySet{d} }},
+	{target: nats.ErrMsgNotBound, temporary: false, wrap: func(d data) error { return MsgNotBound{d} }},
+	{target: nats.ErrMsgNoReply, temporary: false, wrap: func(d data) error { return MsgNoReply{d} }},
+	{target: nats.ErrClientIPNotSupported, temporary: false, wrap: func(d data) error { return ClientIPNotSupported{d} }},
+	{target: nats.ErrDisconnected, temporary: true, wrap: func(d data) error { return Disconnected{d} }},
+	{target: nats.ErrHeadersNotSupported, temporary: false, wrap: func(d data) error { return HeadersNotSupported{d} }},
+	{target: nats.ErrBadHeaderMsg, temporary: false, wrap: func(d data) error { return BadHeaderMsg{d} }},
+	{target: nats.ErrNoResponders, temporary: true, wrap: func(d data) error { return NoResponders{d} }},
+	{target: nats.ErrNoContextOrTimeout, temporary: false, wrap: func(d data) error { return NoContextOrTimeout{d} }},
+	{target: nats.ErrPullModeNotAllowed, temporary: false, wrap: func(d data) error { return PullModeNotAllowed{d} }},
+	{target: nats.ErrJetStreamNotEnabled, temporary: false, wrap: func(d data) error { return JetStreamNotEnabled{d} }},
+	{target: nats.ErrJetStreamBadPre, temporary: false, wrap: func(d data) error { return JetStreamBadPre{d} }},
+	{target: nats.ErrNoStreamResponse, temporary: false, wrap: func(d data) error { return NoStreamResponse{d} }},
+	{target: nats.ErrNotJSMessage, temporary: false, wrap: func(d data) error { return NotJSMessage{d} }},
+	{target: nats.ErrInvalidStreamName, temporary: false, wrap: func(d data) error { return InvalidStreamName{d} }},
+	{target: nats.ErrInvalidDurableName, temporary: false, wrap: func(d data) error { return InvalidDurableName{d} }},
+	{target: nats.ErrNoMatchingStream, temporary: false, wrap: func(d data) error { return NoMatchingStream{d} }},
+	{target: nats.ErrSubjectMismatch, temporary: false, wrap: func(d data) error { return SubjectMismatch{d} }},
+	{target: nats.ErrContextAndTimeout, temporary: false, wrap: func(d data) error { return ContextAndTimeout{d} }},
+	{target: nats.ErrInvalidJSAck, temporary: false, wrap: func(d data) error { return InvalidJSAck{d} }},
+	{target: nats.ErrMultiStreamUnsupported, temporary: false, wrap: func(d data) error { return MultiStreamUnsupported{d} }},
+	{target: nats.ErrStreamNameRequired, temporary: false, wrap: func(d data) error { return StreamNameRequired{d} }},
+	{target: nats.ErrStreamNotFound, temporary: false, wrap: func(d data) error { return StreamNotFound{d} }},
+	{target: nats.ErrConsumerNotFound, temporary: false, wrap: func(d data) error { return ConsumerNotFound{d} }},
+	{target: nats.ErrConsumerNameRequired, temporary: false, wrap: func(d data) error { return ConsumerNameRequired{d} }},
+	{target: nats.ErrConsumerConfigRequired, temporary: false, wrap: func(d data) error { return ConsumerConfigRequired{d} }},
+	{target: nats.ErrStreamSnapshotConfigRequired, temporary: false, wrap: func(d data) error { return StreamSnapshotConfigRequired{d} }},
+	{target: nats.ErrDeliverSubjectRequired, temporary: false, wrap: func(d data) error { return DeliverSubjectRequired{d} }},
+	{target: nats.ErrPullSubscribeToPushConsumer, temporary: false, wrap: func(d data) error { return PullSubscribeToPushConsumer{d} }},
+	{target: nats.ErrPullSubscribeRequired, temporary: false, wrap: func(d data) error { return PullSubscribeRequired{d} }},
+	{target: nats.ErrConsumerNotActive, temporary: false, wrap: func(d data) error { return ConsumerNotActive{d} }},
+	{target: nats.ErrMsgNotFound, temporary: false, wrap: func(d data) error { return MsgNotFound{d} }},
+}
+
+// convertErr - wraps the NATS error in the error type of the client, other errors are returned as is
 func convertErr(err error) error {
 	if err == nil {
 		return err
 	}
 
-	switch err.Error() {
-	case "nats: connection closed":
-		return ConnectionClosed{data: data{m: convertString(err)}}
-	case "nats: connection draining":
-		return ConnectionDraining{data: data{m: convertString(err)}}
-	case "nats: draining connection timed out":
-		return DrainTimeout{data: data{m: convertString(err)}}
-	case "nats: connection reconnecting":
-		return ConnectionReconnecting{data: data{m: convertString(err)}}
-	case "nats: secure connection required":
-		return SecureConnRequired{data: data{m: convertString(err)}}
-	case "nats: secure connection not available":
-		return SecureConnWanted{data: data{m: convertString(err)}}
-	case "nats: invalid subscription":
-		return BadSubscription{data: data{m: convertString(err)}}
-	case "nats: invalid subscription type":
-		return TypeSubscription{data: data{m: convertString(err)}}
-	case "nats: invalid subject":
-		return BadSubject{data: data{m: convertString(err)}}
-	case "nats: invalid queue name":
-		return BadQueueName{data: data{m: convertString(err)}}
-	case "nats: slow consumer, messages dropped":
-		return SlowConsumer{data: data{m: convertString(err)}}
-	case "nats: timeout":
-		return Timeout{data: data{m: convertString(err)}}
-	case "nats: timeout invalid":
-		return BadTimeout{data: data{m: convertString(err)}}
-	case "nats: authorization violation":
-		return Authorization{data: data{m: convertString(err)}}
-	case "nats: authentication expired":
-		return AuthExpired{data: data{m: convertString(err)}}
-	case "nats: authentication revoked":
-		return AuthRevoked{data: data{m: convertString(err)}}
-	case "nats: account authentication expired":
-		return AccountAuthExpired{data: data{m: convertString(err)}}
-	case "nats: no servers available for connection":
-		return NoServers{data: data{m: convertString(err)}}
-	case "nats: connect message, json parse error":
-		return JsonParse{data: data{m: convertString(err)}}
-	case "nats: argument needs to be a channel type":
-		return ChanArg{data: data{m: convertString(err)}}
-	case "nats: maximum payload exceeded":
-		return MaxPayload{data: data{m: convertString(err)}}
-	case "nats: maximum messages delivered":
-		return MaxMessages{data: data{m: convertString(err)}}
-	case "nats: illegal call on an async subscription":
-		return SyncSubRequired{data: data{m: convertString(err)}}
-	case "nats: multiple tls.Configs not allowed":
-		return MultipleTLSConfigs{data: data{m: convertString(err)}}
-	case "nats: protocol exception, INFO not received":
-		return NoInfoReceived{data: data{m: convertString(err)}}
-	case "nats: outbound buffer limit exceeded":
-		return ReconnectBufExceeded{data: data{m: convertString(err)}}
-	case "nats: invalid connection":
-		return InvalidConnection{data: data{m: convertString(err)}}
-	case "nats: invalid message or message nil":
-		return InvalidMsg{data: data{m: convertString(err)}}
-	case "nats: invalid argument":
-		return InvalidArg{data: data{m: convertString(err)}}
-	case "nats: invalid context":
-		return InvalidContext{data: data{m: convertString(err)}}
-	case "nats: context requires a deadline":
-		return NoDeadlineContext{data: data{m: convertString(err)}}
-	case "nats: no echo option not supported by this server":
-		return NoEchoNotSupported{data: data{m: convertString(err)}}
-	case "nats: client ID not supported by this server":
-		return ClientIDNotSupported{data: data{m: convertString(err)}}
-	case "nats: user callback defined without a signature handler":
-		return UserButNoSigCB{data: data{m: convertString(err)}}
-	case "nats: nkey defined without a signature handler":
-		return NkeyButNoSigCB{data: data{m: convertString(err)}}
-	case "nats: user callback not defined":
-		return NoUserCB{data: data{m: convertString(err)}}
-	case "nats: user callback and nkey defined":
-		return NkeyAndUser{data: data{m: convertString(err)}}
-	case "nats: nkeys not supported by the server":
-		return NkeysNotSupported{data: data{m: convertString(err)}}
-	case "nats: " + nats.STALE_CONNECTION:
-		return StaleConnection{data: data{m: convertString(err)}}
-	case "nats: token and token handler both set":
-		return TokenAlreadySet{data: data{m: convertString(err)}}
-	case "nats: message is not bound to subscription/connection":
-		return MsgNotBound{data: data{m: convertString(err)}}
-	case "nats: message does not have a reply":
-		return MsgNoReply{data: data{m: convertString(err)}}
-	case "nats: client IP not supported by this server":
-		return ClientIPNotSupported{data: data{m: convertString(err)}}
-	case "nats: server is disconnected":
-		return Disconnected{data: data{m: convertString(err)}}
-	case "nats: headers not supported by this server":
-		return HeadersNotSupported{data: data{m: convertString(err)}}
-	case "nats: message could not decode headers":
-		return BadHeaderMsg{data: data{m: convertString(err)}}
-	case "nats: no responders available for request":
-		return NoResponders{data: data{m: convertString(err)}}
-	case "nats: no context or timeout given":
-		return NoContextOrTimeout{data: data{m: convertString(err)}}
-	case "nats: pull based not supported":
-		return PullModeNotAllowed{data: data{m: convertString(err)}}
-	case "nats: jetstream not enabled":
-		return JetStreamNotEnabled{data: data{m: convertString(err)}}
-	case "nats: jetstream api prefix not valid":
-		return JetStreamBadPre{data: data{m: convertString(err)}}
-	case "nats: no response from stream":
-		return NoStreamResponse{data: data{m: convertString(err)}}
-	case "nats: not a jetstream message":
-		return NotJSMessage{data: data{m: convertString(err)}}
-	case "nats: invalid stream name":
-		return InvalidStreamName{data: data{m: convertString(err)}}
-	case "nats: invalid durable name":
-		return InvalidDurableName{data: data{m: convertString(err)}}
-	case "nats: no stream matches subject":
-		return NoMatchingStream{data: data{m: convertString(err)}}
-	case "nats: subject does not match consumer":
-		return SubjectMismatch{data: data{m: convertString(err)}}
-	case "nats: context and timeout can not both be set":
-		return ContextAndTimeout{data: data{m: convertString(err)}}
-	case "nats: invalid jetstream publish response":
-		return InvalidJSAck{data: data{m: convertString(err)}}
-	case "nats: multiple streams are not supported":
-		return MultiStreamUnsupported{data: data{m: convertString(err)}}
-	case "nats: stream name is required":
-		return StreamNameRequired{data: data{m: convertString(err)}}
-	case "nats: stream not found":
-		return StreamNotFound{data: data{m: convertString(err)}}
-	case "nats: consumer not found":
-		return ConsumerNotFound{data: data{m: convertString(err)}}
-	case "nats: consumer name is required":
-		return ConsumerNameRequired{data: data{m: convertString(err)}}
-	case "nats: consumer configuration is required":
-		return ConsumerConfigRequired{data: data{m: convertString(err)}}
-	case "nats: stream snapshot configuration is required":
-		return StreamSnapshotConfigRequired{data: data{m: convertString(err)}}
-	case "nats: deliver subject is required":
-		return DeliverSubjectRequired{data: data{m: convertString(err)}}
-	case "nats: cannot pull subscribe to push based consumer":
-		return PullSubscribeToPushConsumer{data: data{m: convertString(err)}}
-	case "nats: must use pull subscribe to bind to pull based consumer":
-		return PullSubscribeRequired{data: data{m: convertString(err)}}
-	case "nats: consumer not active":
-		return ConsumerNotActive{data: data{m: convertString(err)}}
-	case "nats: message not found":
-		return MsgNotFound{data: data{m: convertString(err)}}
-	default:
-		return err
+	for _, e := range natsErrors {
+		if errors.Is(err, e.target) {
+			return e.wrap(data{err: err, m: convertString(err), temporary: e.temporary})
+		}
+	}
+
+	return err
+}
+
+// IsRetryable - checks that the error of the client is transient and the operation may be retried
+func IsRetryable(err error) bool {
+	var r interface{ IsRetryable() bool }
+	if errors.As(err, &r) {
+		return r.IsRetryable()
 	}
+
+	return false
 }
 
 func convertString(err error) string {
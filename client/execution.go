@@ -0,0 +1,151 @@
+package client
+
+import (
+	"context"
+	"reflect"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/nats-io/nats.go"
+)
+
+// Execution - mode of execution of handlers of the subscription
+type Execution int
+
+const (
+	// ExecSerial - messages are handled one by one in the order of delivery, by default
+	ExecSerial Execution = iota
+	// ExecPool - messages are handled concurrently by the pool of workers, the order is not kept, while all workers
+	// are busy the messages wait in the pending buffer of the subscription
+	ExecPool
+	// ExecUnbounded - each message is handled by its own goroutine, the order is not kept
+	ExecUnbounded
+)
+
+// executor - runs handlers of the subscription by the execution mode
+type executor struct {
+	mode        Execution
+	workers     int
+	maxInFlight int32
+	inFlight    int32
+	// jobs - messages passed to workers, messages admitted by the max in-flight limit are queued
+	// without blocking, otherwise the dispatcher waits for a free worker
+	jobs chan func()
+	// mux - queuing holds the read lock, stopped workers set stopped under the write lock and run the rest of jobs
+	mux     sync.RWMutex
+	stopped bool
+}
+
+// start - starts the pool of workers stopped by the context of the subscription,
+// by default the pool has a worker per CPU
+func (e *executor) start(ctx context.Context) {
+	if e.mode != ExecPool {
+		return
+	}
+
+	if e.workers <= 0 {
+		e.workers = runtime.NumCPU()
+	}
+	e.jobs = make(chan func(), e.maxInFlight)
+	for i := 0; i < e.workers; i++ {
+		go e.work(ctx)
+	}
+}
+
+// work - runs queued messages until the context is canceled, then runs the rest of the queue
+func (e *executor) work(ctx context.Context) {
+	for {
+		select {
+		case run := <-e.jobs:
+			run()
+		case <-ctx.Done():
+			e.mux.Lock()
+			e.stopped = true
+			e.mux.Unlock()
+
+			for {
+				select {
+				case run := <-e.jobs:
+					run()
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// queue - passes the message to the pool, the message is run by the dispatcher after the pool is stopped
+func (e *executor) queue(ctx context.Context, run func()) {
+	e.mux.RLock()
+	if e.stopped {
+		e.mux.RUnlock()
+		run()
+		return
+	}
+
+	select {
+	case e.jobs <- run:
+		e.mux.RUnlock()
+	case <-ctx.Done():
+		e.mux.RUnlock()
+		run()
+	}
+}
+
+// dispatch - runs the handler of the message by the execution mode of the subscription,
+// the message exceeding the rate limit or the max in-flight limit is rejected with ResourceExhausted
+func (s *subscription) dispatch(msg *nats.Msg, cb nats.MsgHandler) {
+	if err := s.limits.allow(msg.Subject); err != nil {
+		s.reject(msg, err)
+		return
+	}
+
+	var e = &s.exec
+	if n := atomic.AddInt32(&e.inFlight, 1); e.maxInFlight > 0 && n > e.maxInFlight {
+		atomic.AddInt32(&e.inFlight, -1)
+		s.reject(msg, Errorf(CodeResourceExhausted, "too many messages in flight: %d", e.maxInFlight))
+		return
+	}
+
+	// waiting messages are counted as running, unsubscribing and closing wait for them
+	s.running.add()
+	var run = func() {
+		defer func() {
+			atomic.AddInt32(&e.inFlight, -1)
+			s.running.done()
+		}()
+		cb(msg)
+	}
+
+	switch e.mode {
+	case ExecPool:
+		e.queue(s.ctx, run)
+	case ExecUnbounded:
+		go run()
+	default:
+		run()
+	}
+}
+
+// reject - replies the error to the caller or fails the notification without calling the handler
+func (s *subscription) reject(msg *nats.Msg, err error) {
+	var kind = KindCall
+	if s.process.Type().NumOut() == 1 {
+		kind = KindNotify
+	}
+	s.metrics.ObserveHandler(s.Subscription.Subject, kind, OutcomeRejected, 0)
+	s.log.Debugw("Reject", "subject", s.Subscription.Subject, "kind", kind, "error", err)
+
+	if kind == KindNotify {
+		s.fail(s.ctx, msg.Subject, msg.Data, SessionDTO{}, nil, err, 0)
+		return
+	}
+
+	var dtoValue = newResponseDTO(s.process.Type().Out(0))
+	dtoValue.FieldByName("Error").Set(reflect.ValueOf(newErrorDTO(err)))
+	if err = s.response(msg.Reply, dtoValue.Addr().Interface()); err != nil {
+		s.log.Debugw("Reject", "subject", s.Subscription.Subject, "reply error", err)
+	}
+}
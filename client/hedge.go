@@ -0,0 +1,224 @@
+package client
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+const (
+	// hedgeSamples - number of recent latencies of the pattern kept for the percentile
+	hedgeSamples = 100
+	// hedgeMinSamples - number of latencies required for the percentile, Delay is used before
+	hedgeMinSamples = 10
+)
+
+// HedgePolicy - copies of calls sent while there is no reply, the first successful reply wins and the other copies
+// are canceled, copies have the same request ID, so handlers can recognize them
+//
+// hedging is intended for read-only calls served by queue groups
+type HedgePolicy struct {
+	// Copies - maximum number of additional copies of the call, 0 - the call is not hedged
+	Copies int
+	// Delay - time without a reply before the next copy is sent
+	Delay time.Duration
+	// Percentile - the delay is the percentile of latencies of recent calls of subjects matching the pattern
+	// (0.95 - p95), Delay is used until enough calls are measured
+	Percentile float64
+}
+
+// HedgeMetrics - optional interface of Metrics receiving results of hedged calls
+type HedgeMetrics interface {
+	// ObserveHedge - copies of the call were sent, winner is the number of the copy which replied first
+	// with success, 0 - the original call, -1 - all copies failed
+	ObserveHedge(subject string, copies, winner int)
+}
+
+// hedgeRule - hedge policy and recent latencies of subjects matching the pattern
+type hedgeRule struct {
+	pattern string
+	policy  HedgePolicy
+	samples *samples
+}
+
+// hedging - hedge policies and recent latencies of patterns, subjects matching the pattern share its latencies
+type hedging struct {
+	mux   sync.Mutex
+	rules []hedgeRule
+}
+
+// samples - the ring of recent latencies
+type samples struct {
+	values []time.Duration
+	next   int
+}
+
+// set - sets the policy of the pattern keeping its latencies
+func (h *hedging) set(pattern string, policy HedgePolicy) {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	for i := range h.rules {
+		if h.rules[i].pattern == pattern {
+			h.rules[i].policy = policy
+			return
+		}
+	}
+	h.rules = append(h.rules, hedgeRule{pattern: pattern, policy: policy, samples: &samples{}})
+}
+
+// policy - returns the policy of the subject, the delay of copies and latencies of the pattern,
+// nil if the subject is not hedged
+func (h *hedging) policy(subject string) (HedgePolicy, time.Duration, *samples) {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	for _, r := range h.rules {
+		if !matchSubject(r.pattern, subject) {
+			continue
+		}
+
+		var s = r.samples
+		if r.policy.Percentile <= 0 || len(s.values) < hedgeMinSamples {
+			return r.policy, r.policy.Delay, s
+		}
+
+		var sorted = append([]time.Duration(nil), s.values...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		var i = int(math.Ceil(r.policy.Percentile*float64(len(sorted)))) - 1
+		if i < 0 {
+			i = 0
+		} else if i >= len(sorted) {
+			i = len(sorted) - 1
+		}
+
+		return r.policy, sorted[i], s
+	}
+
+	return HedgePolicy{}, 0, nil
+}
+
+// observe - remembers the latency of the reply
+func (h *hedging) observe(s *samples, d time.Duration) {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	if len(s.values) < hedgeSamples {
+		s.values = append(s.values, d)
+		return
+	}
+	s.values[s.next] = d
+	s.next = (s.next + 1) % hedgeSamples
+}
+
+// hedgeReply - the reply to the copy of the call
+type hedgeReply struct {
+	hedge   int
+	dto     reflect.Value
+	err     error
+	elapsed time.Duration
+}
+
+// exchange - sends the call and receives the reply DTO, the hedged call sends copies while there is no reply
+func (c *Client) exchange(ctx context.Context, subject string, session SessionDTO, request interface{},
+	respType reflect.Type) (reflect.Value, error) {
+	var policy, delay, latencies = c.hedging.policy(subject)
+	if latencies == nil {
+		return c.send(ctx, subject, session, request, respType)
+	}
+
+	if policy.Copies <= 0 || delay <= 0 {
+		var start = time.Now()
+		respDTO, err := c.send(ctx, subject, session, request, respType)
+		if err == nil {
+			c.hedging.observe(latencies, time.Since(start))
+		}
+		return respDTO, err
+	}
+
+	// the copies which lose are canceled by the context
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		replies = make(chan hedgeReply, policy.Copies+1)
+		timer   = time.NewTimer(delay)
+		sent    int
+		pending int
+	)
+	defer timer.Stop()
+
+	var launch = func() {
+		var copySession = session
+		copySession.Hedge = sent
+		go func(hedge int) {
+			var start = time.Now()
+			dto, err := c.send(ctx, subject, copySession, request, respType)
+			replies <- hedgeReply{hedge: hedge, dto: dto, err: err, elapsed: time.Since(start)}
+		}(sent)
+		sent++
+		pending++
+	}
+	launch()
+
+	for {
+		select {
+		case <-timer.C:
+			if sent <= policy.Copies {
+				launch()
+				timer.Reset(delay)
+			}
+		case r := <-replies:
+			pending--
+			if r.err == nil {
+				c.hedging.observe(latencies, r.elapsed)
+			}
+
+			if r.err == nil && r.dto.FieldByName("Error").Interface().(ErrorDTO).Type == nil {
+				c.observeHedge(subject, sent, r.hedge)
+
+				// remote handlers of the other copies are stopped, they have the same request ID
+				if pending > 0 {
+					_ = c.publishMsg(nats.NewMsg(cancelSubject(*session.RequestID)))
+				}
+				return r.dto, nil
+			}
+
+			// the failed copy is not replaced, other copies are sent only while there is no reply
+			if pending == 0 {
+				c.observeHedge(subject, sent, -1)
+				return r.dto, r.err
+			}
+		}
+	}
+}
+
+// send - sends the copy of the call and receives the reply DTO
+func (c *Client) send(ctx context.Context, subject string, session SessionDTO, request interface{},
+	respType reflect.Type) (reflect.Value, error) {
+	var (
+		reqDTO  = newRequestDTO(reflect.TypeOf(request))
+		respDTO = newResponseDTO(respType)
+	)
+	reqDTO.FieldByName("Session").Set(reflect.ValueOf(session))
+	reqDTO.FieldByName("Request").Set(reflect.ValueOf(request))
+
+	return respDTO, c.request(ctx, subject, reqDTO.Interface(), respDTO.Addr().Interface())
+}
+
+// observeHedge - reports the result of the call if copies were sent
+func (c *Client) observeHedge(subject string, copies, winner int) {
+	if copies < 2 {
+		return
+	}
+
+	c.logger(subject).Debugw("Hedge", "subject", subject, "copies", copies, "winner", winner)
+	if m, ok := c.metrics.(HedgeMetrics); ok {
+		m.ObserveHedge(subject, copies, winner)
+	}
+}
@@ -0,0 +1,93 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// CallInfo - the call or the notification seen by interceptors
+type CallInfo struct {
+	Subject string
+	Kind    HandlerKind
+	// RequestID - identifier of the call, empty for notifications
+	RequestID string
+	// Attempt - attempt of the call received by the subscriber, 0 on the client side
+	Attempt int
+	// Hedge - number of the hedged copy of the call received by the subscriber
+	Hedge    int
+	Metadata Metadata
+}
+
+// Invoker - calls the next client interceptor or sends the call, response is nil for Publish
+type Invoker func(ctx context.Context, request, response interface{}) error
+
+// ClientInterceptor - wraps Request and Publish, the interceptor can short-circuit the call returning
+// an error without calling next, the request and the response must keep their types
+type ClientInterceptor func(ctx context.Context, info CallInfo, request, response interface{}, next Invoker) error
+
+// Handler - calls the next server interceptor or the handler of the subscription, response is nil for notify
+type Handler func(ctx context.Context, request interface{}) (response interface{}, err error)
+
+// ServerInterceptor - wraps handlers of calls and notifications, the interceptor can short-circuit the call
+// returning an error or an ErrorDTO without calling next, the client receives it as the error of the handler
+type ServerInterceptor func(ctx context.Context, info CallInfo, request interface{}, next Handler) (interface{}, error)
+
+// intercept - sends the call through the client interceptors, the first one is the outermost
+func (c *Client) intercept(ctx context.Context, info CallInfo, request, response interface{}, invoker Invoker) error {
+	for i := len(c.interceptors) - 1; i >= 0; i-- {
+		var interceptor, next = c.interceptors[i], invoker
+		invoker = func(ctx context.Context, request, response interface{}) error {
+			return interceptor(ctx, info, request, response, next)
+		}
+	}
+
+	return invoker(ctx, request, response)
+}
+
+// intercept - calls the handler through the server interceptors, the first one is the outermost
+func (s *subscription) intercept(ctx context.Context, info CallInfo, request interface{}) (interface{}, error) {
+	var handler Handler = s.callHandler
+	for i := len(s.interceptors) - 1; i >= 0; i-- {
+		var interceptor, next = s.interceptors[i], handler
+		handler = func(ctx context.Context, request interface{}) (interface{}, error) {
+			return interceptor(ctx, info, request, next)
+		}
+	}
+
+	return handler(ctx, request)
+}
+
+// callHandler - calls the handler of the subscription, the last link of the chain
+func (s *subscription) callHandler(ctx context.Context, request interface{}) (interface{}, error) {
+	var req = reflect.ValueOf(request)
+	if !req.IsValid() || req.Type() != s.process.Type().In(1) {
+		return nil, fmt.Errorf("invalid request of interceptor: %T", request)
+	}
+
+	var (
+		out    = s.process.Call([]reflect.Value{reflect.ValueOf(ctx), req})
+		errVal = out[len(out)-1]
+		err    error
+	)
+	if !errVal.IsNil() {
+		err = errVal.Interface().(error)
+	}
+
+	if len(out) == 1 {
+		return nil, err
+	}
+
+	return out[0].Interface(), err
+}
+
+// callInfo - returns the call of the session seen by server interceptors
+func callInfo(ctx context.Context, subject string, kind HandlerKind, session SessionDTO) CallInfo {
+	var info = CallInfo{Subject: subject, Kind: kind, Attempt: session.Attempt, Hedge: session.Hedge,
+		Metadata: MetadataFrom(ctx)}
+	if session.RequestID != nil {
+		info.RequestID = *session.RequestID
+	}
+
+	return info
+}
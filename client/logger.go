@@ -0,0 +1,114 @@
+package client
+
+import (
+	"sync/atomic"
+)
+
+// Logger - minimal structured logger of the client
+//
+// *zap.SugaredLogger implements it, adapters of other loggers are in the logger package
+type Logger interface {
+	Debugw(msg string, keysAndValues ...interface{})
+	Infow(msg string, keysAndValues ...interface{})
+	Warnw(msg string, keysAndValues ...interface{})
+	Errorw(msg string, keysAndValues ...interface{})
+}
+
+// Level - minimum level of entries logged for subjects
+type Level int8
+
+const (
+	DebugLevel Level = iota
+	InfoLevel
+	WarnLevel
+	ErrorLevel
+	// OffLevel - nothing is logged
+	OffLevel
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Debugw(string, ...interface{}) {}
+func (nopLogger) Infow(string, ...interface{})  {}
+func (nopLogger) Warnw(string, ...interface{})  {}
+func (nopLogger) Errorw(string, ...interface{}) {}
+
+// NopLogger - returns logger which discards all entries
+func NopLogger() Logger {
+	return nopLogger{}
+}
+
+// logRule - level and sampling of entries of subjects matching the pattern
+type logRule struct {
+	pattern string
+	level   Level
+	every   uint64
+	counter *uint64
+}
+
+// subjectLogger - filters entries of the subject by level and sampling of the rule
+type subjectLogger struct {
+	Logger
+	rule *logRule
+}
+
+func (l subjectLogger) enabled(level Level) bool {
+	if level < l.rule.level {
+		return false
+	}
+
+	if l.rule.every > 1 {
+		return (atomic.AddUint64(l.rule.counter, 1)-1)%l.rule.every == 0
+	}
+
+	return true
+}
+
+func (l subjectLogger) Debugw(msg string, keysAndValues ...interface{}) {
+	if l.enabled(DebugLevel) {
+		l.Logger.Debugw(msg, keysAndValues...)
+	}
+}
+
+func (l subjectLogger) Infow(msg string, keysAndValues ...interface{}) {
+	if l.enabled(InfoLevel) {
+		l.Logger.Infow(msg, keysAndValues...)
+	}
+}
+
+func (l subjectLogger) Warnw(msg string, keysAndValues ...interface{}) {
+	if l.enabled(WarnLevel) {
+		l.Logger.Warnw(msg, keysAndValues...)
+	}
+}
+
+func (l subjectLogger) Errorw(msg string, keysAndValues ...interface{}) {
+	if l.enabled(ErrorLevel) {
+		l.Logger.Errorw(msg, keysAndValues...)
+	}
+}
+
+// logger - returns logger of the subject, the first matching rule is applied
+func (c *Client) logger(subject string) Logger {
+	for _, r := range c.logRules {
+		if matchSubject(r.pattern, subject) {
+			return subjectLogger{Logger: c.log, rule: r}
+		}
+	}
+
+	return c.log
+}
+
+// rule - returns the rule of the pattern, creates it if not exists
+func (c *Client) rule(pattern string) *logRule {
+	for _, r := range c.logRules {
+		if r.pattern == pattern {
+			return r
+		}
+	}
+
+	var r = &logRule{pattern: pattern, counter: new(uint64)}
+	c.logRules = append(c.logRules, r)
+
+	return r
+}
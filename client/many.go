@@ -0,0 +1,244 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nuid"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// manyOptions - conditions of the end of collecting replies
+type manyOptions struct {
+	count int
+	quiet time.Duration
+}
+
+// Replies - replies of responders of RequestManyIter, are read one by one:
+//
+//	for replies.Next() {
+//		var resp Response
+//		err := replies.Decode(&resp)
+//	}
+//	err = replies.Close()
+type Replies struct {
+	c         *Client
+	ctx       context.Context
+	requestID string
+	sub       *nats.Subscription
+	opts      manyOptions
+	received  int
+	msg       *nats.Msg
+	err       error
+	done      bool
+	finish    func(err error)
+}
+
+// RequestMany - a remote procedure call of all responders of the subject, replies are collected until
+// the count of replies, the quiet period without replies or the deadline of the context
+//
+// responses must be *[]*struct, errs contains the error of each response decoded from its ErrorDTO,
+// err is the error of the call itself
+func (c *Client) RequestMany(ctx context.Context, subject string, request, responses interface{},
+	opts ...ManyOption) (errs []error, err error) {
+	var t = reflect.TypeOf(responses)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Slice {
+		return nil, fmt.Errorf("invalid responses: value is not *[]*struct")
+	}
+
+	var elem = t.Elem().Elem()
+	if err = validateModel(reflect.New(elem).Elem().Interface()); err != nil {
+		return nil, fmt.Errorf("invalid responses: %w", err)
+	}
+
+	replies, err := c.RequestManyIter(ctx, subject, request, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var slice = reflect.ValueOf(responses).Elem()
+	for replies.Next() {
+		var resp = reflect.New(elem.Elem())
+		errs = append(errs, replies.Decode(resp.Interface()))
+		slice = reflect.Append(slice, resp)
+	}
+	reflect.ValueOf(responses).Elem().Set(slice)
+
+	return errs, replies.Close()
+}
+
+// RequestManyIter - a remote procedure call of all responders of the subject, replies are read by the iterator,
+// the iterator must be closed
+func (c *Client) RequestManyIter(ctx context.Context, subject string, request interface{},
+	opts ...ManyOption) (_ *Replies, err error) {
+	var (
+		start   = time.Now()
+		replies = &Replies{c: c, ctx: ctx}
+	)
+	for _, opt := range opts {
+		opt(&replies.opts)
+	}
+
+	// validate request and conditions of the end
+	if err = validateModel(request); err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+
+	if err = validateFields(request); err != nil {
+		return nil, err
+	}
+
+	if _, ok := ctx.Deadline(); !ok && replies.opts.count <= 0 && replies.opts.quiet <= 0 {
+		return nil, fmt.Errorf("replies are not limited: set the count, the quiet period or the deadline")
+	}
+
+	session, err := getSession(ctx, c.metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	replies.requestID = nuid.Next()
+	session.RequestID = &replies.requestID
+
+	_, span := c.tracing.send(ctx, subject, trace.SpanKindClient, &session)
+	replies.finish = func(err error) {
+		endSpan(span, err)
+		c.metrics.ObserveRequest(subject, outcomeOf(err), time.Since(start))
+		c.logger(subject).Debugw("RequestMany",
+			"subject", subject, "elapsed", time.Since(start).Seconds(), "replies", replies.received,
+			"request", c.redact.value(subject, request), "error", err,
+		)
+	}
+
+	// the replies are received by the inbox subscription
+	var msg = nats.NewMsg(subject)
+	msg.Reply = nats.NewInbox()
+
+	var reqDTO = newRequestDTO(reflect.TypeOf(request))
+	reqDTO.FieldByName("Session").Set(reflect.ValueOf(session))
+	reqDTO.FieldByName("Request").Set(reflect.ValueOf(request))
+
+	if msg.Data, err = c.codec.encode(reqDTO.Interface()); err != nil {
+		replies.finish(err)
+		return nil, err
+	}
+
+	if replies.sub, err = c.subscribeSync(msg.Reply); err != nil {
+		err = convertErr(err)
+		replies.finish(err)
+		return nil, err
+	}
+
+	if err = c.publishMsg(msg); err != nil {
+		_ = replies.sub.Unsubscribe()
+		err = convertErr(err)
+		replies.finish(err)
+		return nil, err
+	}
+
+	return replies, nil
+}
+
+// Next - waits for the next reply, returns false when collecting is ended
+func (r *Replies) Next() bool {
+	if r.done {
+		return false
+	}
+
+	if r.opts.count > 0 && r.received >= r.opts.count {
+		r.end(nil)
+		return false
+	}
+
+	var ctx, cancel = r.ctx, context.CancelFunc(func() {})
+	if r.opts.quiet > 0 {
+		ctx, cancel = context.WithTimeout(r.ctx, r.opts.quiet)
+	}
+	defer cancel()
+
+	msg, err := r.sub.NextMsgWithContext(ctx)
+	switch {
+	case errors.Is(err, nats.ErrNoResponders):
+		r.end(convertErr(err))
+	case err == nil:
+		r.msg = msg
+		r.received++
+		return true
+	case r.ctx.Err() == nil, errors.Is(r.ctx.Err(), context.DeadlineExceeded):
+		// the quiet period or the deadline is the normal end if there are replies
+		if r.received == 0 {
+			err = convertErr(nats.ErrTimeout)
+		} else {
+			err = nil
+		}
+		r.end(err)
+	default:
+		r.end(convertErr(err))
+	}
+
+	return false
+}
+
+// Decode - decodes the current reply into the response, returns the error of the responder
+//
+// response must be *struct
+func (r *Replies) Decode(response interface{}) error {
+	if r.msg == nil {
+		return fmt.Errorf("no reply, call Next")
+	}
+
+	if err := validateModel(response); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+
+	var respDTO = newResponseDTO(reflect.TypeOf(response))
+	if err := r.c.codec.decode(r.msg.Data, respDTO.Addr().Interface()); err != nil {
+		return err
+	}
+
+	if !respDTO.FieldByName("Response").IsZero() {
+		reflect.ValueOf(response).Elem().Set(respDTO.FieldByName("Response").Elem())
+	}
+
+	if dto := respDTO.FieldByName("Error").Interface().(ErrorDTO); dto.Type != nil {
+		return dto.reconstruct()
+	}
+
+	if err := validateFields(response); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+
+	return nil
+}
+
+// Err - returns the error of the call which ended collecting
+func (r *Replies) Err() error {
+	return r.err
+}
+
+// Close - stops collecting replies, returns the error of the call
+func (r *Replies) Close() error {
+	r.end(nil)
+	return r.err
+}
+
+// end - ends collecting replies, the first error is kept
+func (r *Replies) end(err error) {
+	if r.done {
+		return
+	}
+	r.done, r.err, r.msg = true, err, nil
+
+	_ = r.sub.Unsubscribe()
+
+	// stop remote handlers, the results are no longer needed
+	if errors.Is(r.ctx.Err(), context.Canceled) {
+		_ = r.c.publishMsg(nats.NewMsg(cancelSubject(r.requestID)))
+	}
+
+	r.finish(err)
+}
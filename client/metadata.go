@@ -0,0 +1,128 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+const (
+	defaultMetadataMaxKeys = 32
+	defaultMetadataMaxSize = 4096
+)
+
+// Metadata - arbitrary values transferred along the call chain (tenant, user, locale, feature flags)
+type Metadata map[string]string
+
+// MetadataPolicy - limits of metadata transferred and received by the client
+type MetadataPolicy struct {
+	// Allow - allowed keys, if empty all keys are allowed
+	Allow []string
+	// MaxKeys - maximum number of keys
+	MaxKeys int
+	// MaxSize - maximum total size of keys and values in bytes
+	MaxSize int
+}
+
+// WithMetadata - returns a copy of the context with the metadata value
+//
+// metadata of the handler context is forwarded automatically when the handler makes downstream calls with it
+func WithMetadata(ctx context.Context, key, value string) context.Context {
+	var md = MetadataFrom(ctx)
+	if md == nil {
+		md = make(Metadata, 1)
+	}
+	md[key] = value
+
+	return context.WithValue(ctx, contextKeyMetadata, md)
+}
+
+// MetadataFrom - returns a copy of the context metadata, or nil
+func MetadataFrom(ctx context.Context) Metadata {
+	if ctx == nil {
+		return nil
+	}
+
+	md, ok := ctx.Value(contextKeyMetadata).(Metadata)
+	if !ok {
+		return nil
+	}
+
+	var cp = make(Metadata, len(md))
+	for k, v := range md {
+		cp[k] = v
+	}
+
+	return cp
+}
+
+// allowed - checks the key by allowlist
+func (p MetadataPolicy) allowed(key string) bool {
+	if len(p.Allow) == 0 {
+		return true
+	}
+
+	for _, a := range p.Allow {
+		if a == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validate - removes keys not from the allowlist and checks limits of the metadata for sending
+func (p MetadataPolicy) validate(md Metadata) (Metadata, error) {
+	var size int
+	for k, v := range md {
+		if !p.allowed(k) {
+			delete(md, k)
+			continue
+		}
+		size += len(k) + len(v)
+	}
+
+	switch {
+	case p.MaxKeys > 0 && len(md) > p.MaxKeys:
+		return nil, fmt.Errorf("number of keys %d exceeds limit %d", len(md), p.MaxKeys)
+	case p.MaxSize > 0 && size > p.MaxSize:
+		return nil, fmt.Errorf("size %d exceeds limit %d", size, p.MaxSize)
+	}
+
+	return md, nil
+}
+
+// filter - removes keys not from the allowlist and keys out of limits from the received metadata
+func (p MetadataPolicy) filter(md Metadata) Metadata {
+	var keys = make([]string, 0, len(md))
+	for k := range md {
+		if p.allowed(k) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var (
+		size int
+		res  = make(Metadata, len(keys))
+	)
+	for _, k := range keys {
+		if p.MaxKeys > 0 && len(res) >= p.MaxKeys {
+			break
+		}
+		if p.MaxSize > 0 && size+len(k)+len(md[k]) > p.MaxSize {
+			continue
+		}
+		size += len(k) + len(md[k])
+		res[k] = md[k]
+	}
+
+	return res
+}
+
+func defaultMetadataPolicy() MetadataPolicy {
+	return MetadataPolicy{
+		MaxKeys: defaultMetadataMaxKeys,
+		MaxSize: defaultMetadataMaxSize,
+	}
+}
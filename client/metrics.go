@@ -0,0 +1,85 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Outcome - result of a call, notification or handler execution
+type Outcome string
+
+const (
+	OutcomeOK           Outcome = "ok"
+	OutcomeError        Outcome = "error"
+	OutcomeTimeout      Outcome = "timeout"
+	OutcomeNoResponders Outcome = "no_responders"
+	OutcomeCanceled     Outcome = "canceled"
+	OutcomeFailed       Outcome = "failed"
+	OutcomePanic        Outcome = "panic"
+	OutcomeRejected     Outcome = "rejected"
+)
+
+// HandlerKind - kind of the subscription handler
+type HandlerKind string
+
+const (
+	KindCall   HandlerKind = "call"
+	KindNotify HandlerKind = "notify"
+)
+
+// Metrics - receives measurements of the client, an implementation exports them to a monitoring system
+type Metrics interface {
+	// ObserveRequest - the call is completed
+	ObserveRequest(subject string, outcome Outcome, elapsed time.Duration)
+	// ObservePublish - the notification is published
+	ObservePublish(subject string, outcome Outcome)
+	// ObserveHandler - the handler of the subscription is completed
+	ObserveHandler(subject string, kind HandlerKind, outcome Outcome, elapsed time.Duration)
+	// AddInFlight - changes the number of running handlers of the subscription
+	AddInFlight(subject string, kind HandlerKind, delta int)
+	// SetPending - sets the number of messages waiting for processing in the subscription
+	SetPending(subject string, msgs int)
+}
+
+type nopMetrics struct{}
+
+func (nopMetrics) ObserveRequest(string, Outcome, time.Duration)              {}
+func (nopMetrics) ObservePublish(string, Outcome)                             {}
+func (nopMetrics) ObserveHandler(string, HandlerKind, Outcome, time.Duration) {}
+func (nopMetrics) AddInFlight(string, HandlerKind, int)                       {}
+func (nopMetrics) SetPending(string, int)                                     {}
+
+// outcomeOf - classifies the error of a call or notification
+func outcomeOf(err error) Outcome {
+	switch {
+	case err == nil:
+		return OutcomeOK
+	case errors.As(err, &ErrorDTO{}):
+		return OutcomeError
+	case errors.As(err, &Timeout{}), errors.Is(err, context.DeadlineExceeded):
+		return OutcomeTimeout
+	case errors.As(err, &NoResponders{}):
+		return OutcomeNoResponders
+	case errors.Is(err, context.Canceled):
+		return OutcomeCanceled
+	default:
+		return OutcomeFailed
+	}
+}
+
+// handlerOutcome - classifies the error of the handler, any error except of context errors is the handler error
+func handlerOutcome(err error) Outcome {
+	switch {
+	case err == nil:
+		return OutcomeOK
+	case errors.As(err, new(*PanicError)):
+		return OutcomePanic
+	case errors.Is(err, context.DeadlineExceeded):
+		return OutcomeTimeout
+	case errors.Is(err, context.Canceled):
+		return OutcomeCanceled
+	default:
+		return OutcomeError
+	}
+}
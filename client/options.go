@@ -0,0 +1,257 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Option - configures the client
+type Option func(c *Client)
+
+// WithMetadataPolicy - sets the allowlist and limits of metadata transferred and received by the client
+func WithMetadataPolicy(policy MetadataPolicy) Option {
+	return func(c *Client) {
+		c.metadata = policy
+	}
+}
+
+// WithContext - sets the parent context of all handler contexts, by default context.Background()
+func WithContext(ctx context.Context) Option {
+	return func(c *Client) {
+		c.ctx = ctx
+	}
+}
+
+// WithGracePeriod - sets the time given to in-flight handlers before their contexts are canceled
+// by unsubscribing, draining or closing
+func WithGracePeriod(d time.Duration) Option {
+	return func(c *Client) {
+		c.grace = d
+	}
+}
+
+// WithAsyncTimeout - sets the timeout of asynchronous calls whose context has no deadline, by default a minute
+func WithAsyncTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		if d > 0 {
+			c.asyncTimeout = d
+		}
+	}
+}
+
+// WithTracerProvider - sets the provider of tracers of calls and notifications, by default the global provider
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *Client) {
+		c.tracing.tracer = tp.Tracer(tracerName)
+	}
+}
+
+// WithPropagator - sets the propagator of the trace context, by default W3C Trace Context
+func WithPropagator(p propagation.TextMapPropagator) Option {
+	return func(c *Client) {
+		c.tracing.propagator = p
+	}
+}
+
+// WithMetrics - sets the receiver of measurements of calls, notifications and subscriptions
+func WithMetrics(m Metrics) Option {
+	return func(c *Client) {
+		c.metrics = m
+	}
+}
+
+// WithLogLevel - sets the minimum level of entries of subjects matching the pattern with NATS wildcards
+func WithLogLevel(pattern string, level Level) Option {
+	return func(c *Client) {
+		c.rule(pattern).level = level
+	}
+}
+
+// WithLogSampling - logs only every n-th entry of subjects matching the pattern with NATS wildcards
+func WithLogSampling(pattern string, n int) Option {
+	return func(c *Client) {
+		c.rule(pattern).every = uint64(n)
+	}
+}
+
+// WithRedactHook - sets the hook replacing requests and responses of the subject before they are logged,
+// fields tagged `wcnats:"redact"` are hidden after the hook
+func WithRedactHook(hook RedactHook) Option {
+	return func(c *Client) {
+		c.redact.hook = hook
+	}
+}
+
+// WithMaxLoggedSize - truncates logged requests and responses to n bytes of JSON, 0 - without limit
+func WithMaxLoggedSize(n int) Option {
+	return func(c *Client) {
+		c.redact.maxSize = n
+	}
+}
+
+// WithHooks - sets callbacks of events of the client
+func WithHooks(h Hooks) Option {
+	return func(c *Client) {
+		c.hooks = h
+	}
+}
+
+// WithRetryPolicy - sets retries of calls of subjects matching the pattern with NATS wildcards,
+// the pattern ">" sets the policy of all subjects
+func WithRetryPolicy(pattern string, p RetryPolicy) Option {
+	return func(c *Client) {
+		for i := range c.retryRules {
+			if c.retryRules[i].pattern == pattern {
+				c.retryRules[i].policy = p
+				return
+			}
+		}
+		c.retryRules = append(c.retryRules, retryRule{pattern: pattern, policy: p})
+	}
+}
+
+// WithInterceptors - adds interceptors of Request and Publish, the first added is the outermost
+func WithInterceptors(interceptors ...ClientInterceptor) Option {
+	return func(c *Client) {
+		c.interceptors = append(c.interceptors, interceptors...)
+	}
+}
+
+// WithServerInterceptors - adds interceptors of handlers of all subscriptions, they wrap interceptors
+// of the subscription
+func WithServerInterceptors(interceptors ...ServerInterceptor) Option {
+	return func(c *Client) {
+		c.serverInterceptors = append(c.serverInterceptors, interceptors...)
+	}
+}
+
+// WithRateLimit - limits calls and notifications sent to subjects matching the pattern with NATS wildcards,
+// the limit can be changed by SetRateLimit
+func WithRateLimit(pattern string, limit RateLimit) Option {
+	return func(c *Client) {
+		c.limits.set(pattern, limit)
+	}
+}
+
+// WithServerRateLimit - limits calls and notifications received by subscriptions of subjects matching the pattern,
+// excess calls are replied with *RateLimited, the limit can be changed by SetServerRateLimit
+func WithServerRateLimit(pattern string, limit RateLimit) Option {
+	return func(c *Client) {
+		c.serverLimits.set(pattern, limit)
+	}
+}
+
+// WithCircuitBreaker - sets the circuit breaker of calls of subjects matching the pattern with NATS wildcards,
+// each subject has its own circuit, circuits unused for 10 minutes are forgotten
+func WithCircuitBreaker(pattern string, p BreakerPolicy) Option {
+	return func(c *Client) {
+		c.breakers.set(pattern, p)
+	}
+}
+
+// WithHedging - sets hedging of calls of subjects matching the pattern with NATS wildcards,
+// the percentile is measured by latencies of all these subjects
+func WithHedging(pattern string, p HedgePolicy) Option {
+	return func(c *Client) {
+		c.hedging.set(pattern, p)
+	}
+}
+
+// ManyOption - configures collecting replies of RequestMany
+type ManyOption func(o *manyOptions)
+
+// ManyCount - ends collecting after n replies
+func ManyCount(n int) ManyOption {
+	return func(o *manyOptions) {
+		o.count = n
+	}
+}
+
+// ManyQuiet - ends collecting if there is no reply during the period
+func ManyQuiet(d time.Duration) ManyOption {
+	return func(o *manyOptions) {
+		o.quiet = d
+	}
+}
+
+// SubscribeOption - configures the subscription
+type SubscribeOption func(s *subscription)
+
+// SubContext - sets the parent context of handler contexts of the subscription, overrides the client's context
+func SubContext(ctx context.Context) SubscribeOption {
+	return func(s *subscription) {
+		s.ctx = ctx
+	}
+}
+
+// SubGracePeriod - sets the grace period of the subscription, overrides the client's grace period
+func SubGracePeriod(d time.Duration) SubscribeOption {
+	return func(s *subscription) {
+		s.grace = d
+	}
+}
+
+// SubErrorPolicy - sets retries, the error callback and the dead-letter subject of the notify handler
+func SubErrorPolicy(p ErrorPolicy) SubscribeOption {
+	return func(s *subscription) {
+		s.errPolicy = p
+	}
+}
+
+// SubNonIdempotent - the handler is called once for the request ID, retries of callers received within the window
+// are rejected with ErrDuplicateRequest, 0 - the default window of a minute
+func SubNonIdempotent(window time.Duration) SubscribeOption {
+	return func(s *subscription) {
+		s.dedup = newDedup(window)
+	}
+}
+
+// SubQueue - subscribes to the queue group, each message is delivered to one member of the group
+func SubQueue(queue string) SubscribeOption {
+	return func(s *subscription) {
+		s.queue, s.queued = queue, true
+	}
+}
+
+// SubBatchConcurrency - sets the number of items of the batch of RequestBatch handled at the same time,
+// n <= 0 sets the default of 8
+func SubBatchConcurrency(n int) SubscribeOption {
+	return func(s *subscription) {
+		if n <= 0 {
+			n = defaultBatchConcurrency
+		}
+		s.batch = n
+	}
+}
+
+// SubInterceptors - adds interceptors of the handler of the subscription, the first added is the outermost
+func SubInterceptors(interceptors ...ServerInterceptor) SubscribeOption {
+	return func(s *subscription) {
+		s.interceptors = append(s.interceptors, interceptors...)
+	}
+}
+
+// SubExecution - sets the mode of execution of handlers, by default ExecSerial
+func SubExecution(mode Execution) SubscribeOption {
+	return func(s *subscription) {
+		s.exec.mode = mode
+	}
+}
+
+// SubWorkers - handlers are executed by the pool of n workers
+func SubWorkers(n int) SubscribeOption {
+	return func(s *subscription) {
+		s.exec.mode, s.exec.workers = ExecPool, n
+	}
+}
+
+// SubMaxInFlight - limits messages running and waiting for a worker, excess calls are replied
+// with ResourceExhausted and excess notifications are failed by the error policy, 0 - without limit
+func SubMaxInFlight(n int) SubscribeOption {
+	return func(s *subscription) {
+		s.exec.maxInFlight = int32(n)
+	}
+}
@@ -0,0 +1,50 @@
+package client
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError - the panic of the handler, the caller receives it with the code CodeInternal
+//
+// the stack trace is transferred to the caller only in builds with the wcnatsdebug tag
+type PanicError struct {
+	Value string
+	Stack string `json:",omitempty"`
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("handler panic: %s", e.Value)
+}
+
+// StatusCode - returns CodeInternal
+func (e *PanicError) StatusCode() Code {
+	return CodeInternal
+}
+
+// Hooks - callbacks of events of the client
+type Hooks struct {
+	// OnPanic - the handler of the subject panicked, the subscription keeps working
+	OnPanic func(subject string, recovered interface{}, stack []byte)
+	// OnCircuitChange - the state of the circuit breaker of the subject is changed
+	OnCircuitChange func(subject string, from, to CircuitState)
+}
+
+// recoverPanic - converts the recovered panic to the error and reports it to the hook
+func (h Hooks) recoverPanic(subject string, recovered interface{}) error {
+	var stack = debug.Stack()
+	if h.OnPanic != nil {
+		h.OnPanic(subject, recovered, stack)
+	}
+
+	var err = &PanicError{Value: fmt.Sprint(recovered)}
+	if debugBuild {
+		err.Stack = string(stack)
+	}
+
+	return err
+}
+
+func init() {
+	RegisterErrorType("wcnats.Panic", &PanicError{})
+}
@@ -0,0 +1,184 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// RateLimit - the token bucket of the pattern shared by all subjects matching it, Rate tokens are added per second
+// up to Burst
+type RateLimit struct {
+	// Rate - tokens per second, 0 - without limit
+	Rate float64
+	// Burst - maximum number of tokens, calls or notifications sent at once, at least 1
+	Burst int
+	// Wait - the client waits for the token until the deadline of the context instead of failing fast,
+	// ignored by subscribers
+	Wait bool
+}
+
+// RateLimited - the rate limit of the subject is exceeded, the call can be repeated after RetryAfter
+type RateLimited struct {
+	Subject    string
+	RetryAfter time.Duration
+}
+
+func (e *RateLimited) Error() string {
+	return fmt.Sprintf("rate limit of %s is exceeded, retry after %s", e.Subject, e.RetryAfter)
+}
+
+// StatusCode - returns CodeResourceExhausted
+func (e *RateLimited) StatusCode() Code {
+	return CodeResourceExhausted
+}
+
+func init() {
+	RegisterErrorType("wcnats.RateLimited", &RateLimited{})
+}
+
+// rateRule - the limit and the token bucket of subjects matching the pattern
+type rateRule struct {
+	pattern string
+	limit   RateLimit
+	bucket  *bucket
+}
+
+// rateLimiter - token buckets of patterns, a subject takes tokens from the bucket of the first matching pattern,
+// so varying tokens of the subject do not bypass the limit, limits can be changed at runtime
+type rateLimiter struct {
+	mux   sync.Mutex
+	rules []rateRule
+}
+
+// bucket - tokens of the pattern
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// set - sets the limit of the pattern keeping its tokens, the limit with Rate 0 deletes the rule and its bucket
+func (l *rateLimiter) set(pattern string, limit RateLimit) {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	for i := range l.rules {
+		if l.rules[i].pattern == pattern {
+			if limit.Rate <= 0 {
+				l.rules = append(l.rules[:i], l.rules[i+1:]...)
+			} else {
+				l.rules[i].limit = limit
+			}
+			return
+		}
+	}
+
+	if limit.Rate > 0 {
+		l.rules = append(l.rules, rateRule{pattern: pattern, limit: limit})
+	}
+}
+
+// reserve - takes the token of the subject, returns the bucket and the time until the token is available,
+// the waiting limit takes the token in advance
+func (l *rateLimiter) reserve(subject string, now time.Time) (RateLimit, *bucket, time.Duration) {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	var r = l.rule(subject)
+	if r == nil {
+		return RateLimit{}, nil, 0
+	}
+
+	var limit, burst = r.limit, math.Max(float64(r.limit.Burst), 1)
+	if r.bucket == nil {
+		r.bucket = &bucket{tokens: burst, last: now}
+	}
+	var b = r.bucket
+
+	// the bucket is refilled by the time passed, the burst could be decreased at runtime
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*limit.Rate)
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return limit, b, 0
+	}
+
+	var wait = time.Duration((1 - b.tokens) / limit.Rate * float64(time.Second))
+	if limit.Wait {
+		b.tokens--
+	}
+
+	return limit, b, wait
+}
+
+// release - returns the token taken in advance
+func (l *rateLimiter) release(b *bucket) {
+	l.mux.Lock()
+	b.tokens++
+	l.mux.Unlock()
+}
+
+// rule - returns the rule of the first matching pattern, nil if the subject is not limited
+func (l *rateLimiter) rule(subject string) *rateRule {
+	for i := range l.rules {
+		if matchSubject(l.rules[i].pattern, subject) {
+			return &l.rules[i]
+		}
+	}
+
+	return nil
+}
+
+// take - takes the token of the subject, waits for it by the limit or fails with *RateLimited
+func (l *rateLimiter) take(ctx context.Context, subject string) error {
+	var limit, b, wait = l.reserve(subject, time.Now())
+	if wait <= 0 {
+		return nil
+	}
+
+	if !limit.Wait {
+		return &RateLimited{Subject: subject, RetryAfter: wait}
+	}
+
+	// the token would come after the deadline, the call fails at once
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+		l.release(b)
+		return &RateLimited{Subject: subject, RetryAfter: wait}
+	}
+
+	if !sleep(ctx, wait) {
+		l.release(b)
+		return ctx.Err()
+	}
+
+	return nil
+}
+
+// allow - takes the token of the subject of the received message, returns *RateLimited if there is no token
+func (l *rateLimiter) allow(subject string) error {
+	var limit, b, wait = l.reserve(subject, time.Now())
+	if wait <= 0 {
+		return nil
+	}
+
+	if limit.Wait {
+		l.release(b)
+	}
+
+	return &RateLimited{Subject: subject, RetryAfter: wait}
+}
+
+// SetRateLimit - changes the limit of calls and notifications sent to subjects matching the pattern,
+// subjects share the bucket of the pattern, the limit with Rate 0 removes it
+func (c *Client) SetRateLimit(pattern string, limit RateLimit) {
+	c.limits.set(pattern, limit)
+}
+
+// SetServerRateLimit - changes the limit of calls and notifications received by subscriptions of subjects
+// matching the pattern, subjects share the bucket of the pattern, the limit with Rate 0 removes it
+func (c *Client) SetServerRateLimit(pattern string, limit RateLimit) {
+	c.serverLimits.set(pattern, limit)
+}
@@ -0,0 +1,167 @@
+package client
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const (
+	// tagName - struct tag of the client options of fields, `wcnats:"redact"` hides the value in logs
+	tagName = "wcnats"
+
+	redactedValue  = "[REDACTED]"
+	maxRedactDepth = 32
+)
+
+// RedactHook - replaces the value of the subject before it is logged
+type RedactHook func(subject string, v interface{}) interface{}
+
+// redactor - prepares requests and responses for logging
+type redactor struct {
+	hook    RedactHook
+	maxSize int
+}
+
+// loggable - the value prepared for logging only when the entry is written
+type loggable struct {
+	r       *redactor
+	subject string
+	v       interface{}
+}
+
+// MarshalJSON - returns JSON of the redacted value, truncated to the max logged size
+func (l loggable) MarshalJSON() ([]byte, error) {
+	var v = l.v
+	if l.r.hook != nil {
+		v = l.r.hook(l.subject, v)
+	}
+
+	data, err := json.Marshal(redactValue(reflect.ValueOf(v), 0))
+	if err != nil {
+		return nil, err
+	}
+
+	if l.r.maxSize > 0 && len(data) > l.r.maxSize {
+		return json.Marshal(fmt.Sprintf("%s...(truncated %d bytes)", data[:l.r.maxSize], len(data)-l.r.maxSize))
+	}
+
+	return data, nil
+}
+
+// String - returns the same representation as JSON for text loggers
+func (l loggable) String() string {
+	data, err := l.MarshalJSON()
+	if err != nil {
+		return err.Error()
+	}
+
+	return string(data)
+}
+
+// value - wraps the value of the subject for logging
+func (r *redactor) value(subject string, v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+
+	return loggable{r: r, subject: subject, v: v}
+}
+
+// redactValue - copies the value replacing fields tagged `wcnats:"redact"`
+func redactValue(v reflect.Value, depth int) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+
+	if depth > maxRedactDepth {
+		return "..."
+	}
+
+	// values with own encoding are logged as is
+	if v.Kind() != reflect.Ptr && v.Kind() != reflect.Interface && v.CanInterface() {
+		switch v.Interface().(type) {
+		case json.Marshaler, encoding.TextMarshaler:
+			return v.Interface()
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return redactValue(v.Elem(), depth+1)
+	case reflect.Struct:
+		var (
+			t   = v.Type()
+			res = make(map[string]interface{}, t.NumField())
+		)
+		for i := 0; i < t.NumField(); i++ {
+			var f = t.Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+
+			name, skip := fieldName(f)
+			switch {
+			case skip:
+			case hasTagOption(f.Tag.Get(tagName), "redact"):
+				res[name] = redactedValue
+			default:
+				res[name] = redactValue(v.Field(i), depth+1)
+			}
+		}
+		return res
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		var res = make(map[string]interface{}, v.Len())
+		for it := v.MapRange(); it.Next(); {
+			res[fmt.Sprint(it.Key().Interface())] = redactValue(it.Value(), depth+1)
+		}
+		return res
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Interface()
+		}
+		var res = make([]interface{}, v.Len())
+		for i := range res {
+			res[i] = redactValue(v.Index(i), depth+1)
+		}
+		return res
+	default:
+		return v.Interface()
+	}
+}
+
+// fieldName - returns the name of the field as JSON encoding does
+func fieldName(f reflect.StructField) (name string, skip bool) {
+	var tag = f.Tag.Get("json")
+	if tag == "-" {
+		return "", true
+	}
+
+	if name = strings.Split(tag, ",")[0]; name == "" {
+		name = f.Name
+	}
+
+	return name, false
+}
+
+// hasTagOption - checks the option in the comma separated tag
+func hasTagOption(tag, option string) bool {
+	for _, o := range strings.Split(tag, ",") {
+		if strings.TrimSpace(o) == option {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,6 @@
+//go:build !wcnatsdebug
+
+package client
+
+// debugBuild - stack traces of handler panics are not transferred to callers
+const debugBuild = false
@@ -0,0 +1,170 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// defaultDedupWindow - how long request IDs are remembered by the non-idempotent subscription
+const defaultDedupWindow = time.Minute
+
+// ErrDuplicateRequest - the non-idempotent handler has already received the request, the caller's retry is rejected
+var ErrDuplicateRequest = Errorf(CodeAborted, "duplicate request")
+
+func init() {
+	RegisterError("wcnats.DuplicateRequest", ErrDuplicateRequest)
+}
+
+// RetryPolicy - retries of Client.Request, all attempts have the same request ID
+type RetryPolicy struct {
+	// MaxAttempts - number of attempts including the first one, 0 or 1 - without retries
+	MaxAttempts int
+	// Backoff - delay before the first retry, doubled for each next retry
+	Backoff time.Duration
+	// MaxBackoff - limit of the delay between retries, 0 - without limit
+	MaxBackoff time.Duration
+	// Jitter - part of the delay from 0 to 1 which is randomized
+	Jitter float64
+	// AttemptTimeout - timeout of an attempt, by default the remaining time of the context deadline
+	// is divided between the remaining attempts
+	AttemptTimeout time.Duration
+	// Retryable - classifies the error of the attempt, by default IsRetryable
+	Retryable func(err error) bool
+}
+
+// RetryMetrics - optional interface of Metrics receiving retries of calls
+type RetryMetrics interface {
+	// ObserveRetry - the attempt of the call is failed and is retried
+	ObserveRetry(subject string, attempt int, outcome Outcome)
+}
+
+// retryRule - retry policy of subjects matching the pattern
+type retryRule struct {
+	pattern string
+	policy  RetryPolicy
+}
+
+// retryPolicy - returns the policy of the subject, the first matching rule is applied
+func (c *Client) retryPolicy(subject string) RetryPolicy {
+	for _, r := range c.retryRules {
+		if matchSubject(r.pattern, subject) {
+			return r.policy
+		}
+	}
+
+	return RetryPolicy{}
+}
+
+// attemptContext - returns context of the attempt with the timeout carved from the deadline of the call
+func (p RetryPolicy) attemptContext(ctx context.Context, attempt int) (context.Context, context.CancelFunc) {
+	var timeout = p.AttemptTimeout
+	if deadline, ok := ctx.Deadline(); ok && timeout == 0 && p.MaxAttempts > attempt {
+		timeout = time.Until(deadline) / time.Duration(p.MaxAttempts-attempt+1)
+	}
+
+	if timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+
+	return context.WithCancel(ctx)
+}
+
+// retry - waits the backoff of the attempt, returns false if the error of the attempt should not be retried
+func (p RetryPolicy) retry(ctx context.Context, attempt int, err error) bool {
+	if attempt >= p.MaxAttempts || ctx.Err() != nil {
+		return false
+	}
+
+	var retryable = p.Retryable
+	if retryable == nil {
+		retryable = IsRetryable
+	}
+	if !retryable(err) {
+		return false
+	}
+
+	var delay = backoff(p.Backoff, p.MaxBackoff, attempt)
+	if p.Jitter > 0 {
+		delay -= time.Duration(p.Jitter * rand.Float64() * float64(delay))
+	}
+
+	return sleep(ctx, delay)
+}
+
+// attemptErr - returns the timeout of the attempt as the retryable error if the call itself is not done
+func attemptErr(ctx, attemptCtx context.Context, err error) error {
+	if ctx.Err() == nil && errors.Is(attemptCtx.Err(), context.DeadlineExceeded) && errors.Is(err, context.DeadlineExceeded) {
+		return Timeout{data{err: err, m: "timeout", temporary: true}}
+	}
+
+	return convertErr(err)
+}
+
+// backoff - returns the exponential delay of the attempt limited by max
+func backoff(base, max time.Duration, attempt int) time.Duration {
+	var delay = base << (attempt - 1)
+	if max > 0 && (delay > max || delay < 0) {
+		delay = max
+	}
+
+	return delay
+}
+
+// sleep - waits the delay, returns false if the context is done earlier
+func sleep(ctx context.Context, delay time.Duration) bool {
+	var timer = time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// dedup - remembers request IDs received by the non-idempotent subscription
+type dedup struct {
+	mux    sync.Mutex
+	window time.Duration
+	swept  time.Time
+	seen   map[string]time.Time
+}
+
+// check - returns ErrDuplicateRequest if the request ID was received within the window
+func (d *dedup) check(id *string) error {
+	if d == nil || id == nil {
+		return nil
+	}
+
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	var now = time.Now()
+	if exp, ok := d.seen[*id]; ok && now.Before(exp) {
+		return ErrDuplicateRequest
+	}
+
+	if now.Sub(d.swept) > d.window {
+		for k, exp := range d.seen {
+			if now.After(exp) {
+				delete(d.seen, k)
+			}
+		}
+		d.swept = now
+	}
+	d.seen[*id] = now.Add(d.window)
+
+	return nil
+}
+
+func newDedup(window time.Duration) *dedup {
+	if window <= 0 {
+		window = defaultDedupWindow
+	}
+
+	return &dedup{window: window, swept: time.Now(), seen: make(map[string]time.Time)}
+}
@@ -0,0 +1,85 @@
+package client
+
+import (
+	"context"
+)
+
+// contextKey - private type of context keys, does not collide with keys of other packages
+type contextKey int
+
+const (
+	contextKeySession contextKey = iota
+	contextKeyCaller
+	contextKeyMetadata
+	contextKeyCall
+)
+
+// legacy string keys of context, are read only for migration
+const (
+	legacyKeySession = "session"
+	legacyKeyService = "service"
+	legacyKeyMethod  = "method"
+)
+
+// Session - data of the call session transferred between services
+type Session struct {
+	ID      string
+	Service string
+	Method  string
+	// RequestID - identifier of the received call, retries and hedged copies of the call have the same identifier
+	RequestID string
+	// Attempt - attempt of the received call
+	Attempt int
+	// Hedge - number of the hedged copy of the received call, 0 - the original call
+	Hedge int
+}
+
+type caller struct {
+	service string
+	method  string
+}
+
+// received - identity of the call received by the handler
+type received struct {
+	requestID string
+	attempt   int
+	hedge     int
+}
+
+// WithSession - returns a copy of the context with the session identifier
+func WithSession(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKeySession, id)
+}
+
+// WithCaller - returns a copy of the context with the service and method of the caller
+func WithCaller(ctx context.Context, service, method string) context.Context {
+	return context.WithValue(ctx, contextKeyCaller, caller{service: service, method: method})
+}
+
+// SessionFrom - returns the session of the context
+//
+// if typed keys are not set, the legacy string keys "session", "service" and "method" are read
+func SessionFrom(ctx context.Context) (s Session) {
+	if ctx == nil {
+		return
+	}
+
+	if id, ok := ctx.Value(contextKeySession).(string); ok {
+		s.ID = id
+	} else {
+		s.ID, _ = ctx.Value(legacyKeySession).(string)
+	}
+
+	if c, ok := ctx.Value(contextKeyCaller).(caller); ok {
+		s.Service, s.Method = c.service, c.method
+	} else {
+		s.Service, _ = ctx.Value(legacyKeyService).(string)
+		s.Method, _ = ctx.Value(legacyKeyMethod).(string)
+	}
+
+	if r, ok := ctx.Value(contextKeyCall).(received); ok {
+		s.RequestID, s.Attempt, s.Hedge = r.requestID, r.attempt, r.hedge
+	}
+
+	return
+}
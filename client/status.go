@@ -0,0 +1,195 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+)
+
+// Code - status code of the remote error, the set is the same as gRPC status codes
+type Code uint32
+
+const (
+	CodeOK Code = iota
+	CodeCanceled
+	CodeUnknown
+	CodeInvalidArgument
+	CodeDeadlineExceeded
+	CodeNotFound
+	CodeAlreadyExists
+	CodePermissionDenied
+	CodeResourceExhausted
+	CodeFailedPrecondition
+	CodeAborted
+	CodeOutOfRange
+	CodeUnimplemented
+	CodeInternal
+	CodeUnavailable
+	CodeDataLoss
+	CodeUnauthenticated
+)
+
+var codeNames = [...]string{
+	CodeOK:                 "OK",
+	CodeCanceled:           "Canceled",
+	CodeUnknown:            "Unknown",
+	CodeInvalidArgument:    "InvalidArgument",
+	CodeDeadlineExceeded:   "DeadlineExceeded",
+	CodeNotFound:           "NotFound",
+	CodeAlreadyExists:      "AlreadyExists",
+	CodePermissionDenied:   "PermissionDenied",
+	CodeResourceExhausted:  "ResourceExhausted",
+	CodeFailedPrecondition: "FailedPrecondition",
+	CodeAborted:            "Aborted",
+	CodeOutOfRange:         "OutOfRange",
+	CodeUnimplemented:      "Unimplemented",
+	CodeInternal:           "Internal",
+	CodeUnavailable:        "Unavailable",
+	CodeDataLoss:           "DataLoss",
+	CodeUnauthenticated:    "Unauthenticated",
+}
+
+func (c Code) String() string {
+	if int(c) < len(codeNames) {
+		return codeNames[c]
+	}
+
+	return "Code(" + strconv.FormatUint(uint64(c), 10) + ")"
+}
+
+// statusCoder - an error with the status code, a handler error implementing it sets the code of the reply
+type statusCoder interface {
+	StatusCode() Code
+}
+
+// statusError - an error with the status code created by Errorf
+type statusError struct {
+	code    Code
+	message string
+}
+
+func (e *statusError) Error() string {
+	return e.message
+}
+
+// StatusCode - returns the status code of the error
+func (e *statusError) StatusCode() Code {
+	return e.code
+}
+
+// Errorf - returns an error with the status code for the reply of the handler
+func Errorf(code Code, format string, args ...interface{}) error {
+	return &statusError{code: code, message: fmt.Sprintf(format, args...)}
+}
+
+// CodeOf - returns the status code of the error: CodeOK for nil, CodeUnknown if the code is not defined
+func CodeOf(err error) Code {
+	var sc statusCoder
+	switch {
+	case err == nil:
+		return CodeOK
+	case errors.As(err, &sc):
+		return sc.StatusCode()
+	case errors.Is(err, context.DeadlineExceeded):
+		return CodeDeadlineExceeded
+	case errors.Is(err, context.Canceled):
+		return CodeCanceled
+	default:
+		return CodeUnknown
+	}
+}
+
+// registeredError - error known to all services, is reconstructed by the client from ErrorDTO
+type registeredError struct {
+	name     string
+	sentinel error
+	typ      reflect.Type
+}
+
+var registry struct {
+	mux    sync.RWMutex
+	errors []registeredError
+}
+
+// RegisterError - registers the sentinel error by name, errors.Is works with it for errors of Client.Request
+//
+// services must register the same errors with the same names
+func RegisterError(name string, sentinel error) {
+	registry.mux.Lock()
+	registry.errors = append(registry.errors, registeredError{name: name, sentinel: sentinel})
+	registry.mux.Unlock()
+}
+
+// RegisterErrorType - registers the type of the error by name, errors.As works with it for errors of Client.Request,
+// exported fields of the error are transferred as details
+//
+// services must register the same types with the same names
+func RegisterErrorType(name string, proto error) {
+	registry.mux.Lock()
+	registry.errors = append(registry.errors, registeredError{name: name, typ: reflect.TypeOf(proto)})
+	registry.mux.Unlock()
+}
+
+// encodeRegistered - finds the registered error in the chain, returns its name and details
+func encodeRegistered(err error) (name string, details json.RawMessage, ok bool) {
+	registry.mux.RLock()
+	defer registry.mux.RUnlock()
+
+	for _, r := range registry.errors {
+		if r.sentinel != nil {
+			if errors.Is(err, r.sentinel) {
+				return r.name, nil, true
+			}
+			continue
+		}
+
+		var target = reflect.New(r.typ)
+		if errors.As(err, target.Interface()) {
+			details, mErr := json.Marshal(target.Elem().Interface())
+			if mErr != nil {
+				details = nil
+			}
+			return r.name, details, true
+		}
+	}
+
+	return "", nil, false
+}
+
+// decodeRegistered - reconstructs the registered error by name and details, or returns nil
+func decodeRegistered(name string, details json.RawMessage) error {
+	registry.mux.RLock()
+	defer registry.mux.RUnlock()
+
+	for _, r := range registry.errors {
+		if r.name != name {
+			continue
+		}
+
+		if r.sentinel != nil {
+			return r.sentinel
+		}
+
+		var v = reflect.New(r.typ)
+		if r.typ.Kind() == reflect.Ptr {
+			v.Elem().Set(reflect.New(r.typ.Elem()))
+		}
+		if len(details) != 0 {
+			if err := json.Unmarshal(details, v.Interface()); err != nil {
+				return nil
+			}
+		}
+
+		if err, ok := v.Elem().Interface().(error); ok {
+			return err
+		}
+
+		return nil
+	}
+
+	return nil
+}
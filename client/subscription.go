@@ -1,11 +1,15 @@
 package client
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"reflect"
+	"sync"
 	"time"
 
 	"github.com/nats-io/nats.go"
-	"go.uber.org/zap"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Subscription interface {
@@ -14,9 +18,28 @@ type Subscription interface {
 
 type subscription struct {
 	*nats.Subscription
-	log      *zap.SugaredLogger
-	process  reflect.Value
-	response reflect.Value
+	ctx          context.Context
+	cancel       context.CancelFunc
+	grace        time.Duration
+	running      tracker
+	log          Logger
+	redact       *redactor
+	codec        codec
+	metadata     MetadataPolicy
+	cancels      *cancelRegistry
+	tracing      tracing
+	metrics      Metrics
+	hooks        Hooks
+	errPolicy    ErrorPolicy
+	dedup        *dedup
+	queue        string
+	queued       bool
+	batch        int
+	exec         executor
+	limits       *rateLimiter
+	interceptors []ServerInterceptor
+	process      reflect.Value
+	response     func(subject string, v interface{}) error
 }
 
 // GetSubject - return subject of subscription
@@ -24,87 +47,239 @@ func (s *subscription) GetSubject() string {
 	return s.Subscription.Subject
 }
 
-// notify - implements the subscriber's notify
-func (s *subscription) notify(values []reflect.Value) []reflect.Value {
-	if s.process.Type().In(1).String() != values[2].FieldByName("Request").Type().String() {
-		s.log.Debugw("wrong notify model", "subject", s.Subscription.Subject,
-			"wait", s.process.Type().In(1), "got", values[2].Type().String(),
-		)
+// stop - cancels contexts of in-flight handlers after the grace period
+func (s *subscription) stop() {
+	time.AfterFunc(s.grace, s.cancel)
+}
+
+// shutdown - waits for in-flight handlers until the deadline, then cancels their contexts
+func (s *subscription) shutdown(deadline time.Time) {
+	s.running.wait(time.Until(deadline))
+	s.cancel()
+}
+
+// begin - counts the running handler
+func (s *subscription) begin(kind HandlerKind) {
+	s.metrics.AddInFlight(s.Subscription.Subject, kind, 1)
+	if msgs, _, err := s.Subscription.Pending(); err == nil {
+		s.metrics.SetPending(s.Subscription.Subject, msgs)
 	}
+}
+
+// end - counts the completed handler
+func (s *subscription) end(kind HandlerKind) {
+	s.metrics.AddInFlight(s.Subscription.Subject, kind, -1)
+}
+
+// invoke - calls the handler through interceptors, the panic of the handler or an interceptor is recovered
+// and returned as *PanicError
+func (s *subscription) invoke(ctx context.Context, info CallInfo, req reflect.Value) (resp interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			resp, err = nil, s.hooks.recoverPanic(s.Subscription.Subject, r)
+		}
+	}()
+
+	return s.intercept(ctx, info, req.Interface())
+}
 
+// notify - implements the subscriber's notify
+func (s *subscription) notify(msg *nats.Msg) {
 	var (
-		start          = time.Now()
-		responseValues []reflect.Value
-		ctx            = createSession(values[2].FieldByName("Session").Interface().(SessionDTO))
-		err            error
+		start    = time.Now()
+		reqDTO   = newRequestDTO(s.process.Type().In(1))
+		session  SessionDTO
+		attempts int
+		err      error
 	)
 	defer func() {
+		s.metrics.ObserveHandler(s.Subscription.Subject, KindNotify, handlerOutcome(err), time.Since(start))
 		s.log.Debugw("Notify", "elapsed", time.Since(start).Seconds(),
-			"subject", s.Subscription.Subject,
-			"request", values[2].Interface(), "error", err,
+			"subject", s.Subscription.Subject, "attempts", attempts,
+			"request", s.redact.value(s.Subscription.Subject, reqDTO.Interface()), "error", err,
 		)
 	}()
 
-	// calling the subscriber
-	responseValues = s.process.Call([]reflect.Value{reflect.ValueOf(ctx), values[2].FieldByName("Request")})
-	if !responseValues[0].IsZero() {
-		err = responseValues[0].Interface().(error)
+	s.begin(KindNotify)
+	defer s.end(KindNotify)
+
+	// decoding the message
+	if err = s.codec.decode(msg.Data, reqDTO.Addr().Interface()); err != nil {
+		s.fail(s.ctx, msg.Subject, msg.Data, session, nil, err, attempts)
+		return
+	}
+
+	// the deadline of the publisher is ignored, the notification is handled even if it waited in the queue
+	session = reqDTO.FieldByName("Session").Interface().(SessionDTO)
+	session.Deadline = nil
+
+	ctx, cancel := createSession(s.ctx, session, s.metadata)
+	defer cancel()
+
+	ctx, span := s.tracing.process(ctx, msg.Subject, trace.SpanKindConsumer, session)
+	defer func() { endSpan(span, err) }()
+
+	// the subscription is stopped, the notification is not handled
+	if err = ctx.Err(); err != nil {
+		s.fail(ctx, msg.Subject, msg.Data, session, reqDTO.FieldByName("Request").Interface(), err, attempts)
+		return
 	}
 
-	return nil
+	// the invalid notification is not retried
+	if err = validateFields(reqDTO.FieldByName("Request").Interface()); err != nil {
+		s.fail(ctx, msg.Subject, msg.Data, session, reqDTO.FieldByName("Request").Interface(), err, attempts)
+		return
+	}
+
+	// calling the subscriber, the failed handler is retried by the error policy
+	var info = callInfo(ctx, msg.Subject, KindNotify, session)
+	for attempts = 1; ; attempts++ {
+		if _, err = s.invoke(ctx, info, reqDTO.FieldByName("Request")); err == nil || !s.errPolicy.retry(ctx, attempts) {
+			break
+		}
+	}
+
+	if err != nil {
+		s.fail(ctx, msg.Subject, msg.Data, session, reqDTO.FieldByName("Request").Interface(), err, attempts)
+	}
 }
 
 // call - implements the subscriber's call and the response to the client who created the call
-func (s *subscription) call(values []reflect.Value) []reflect.Value {
-	if s.process.Type().In(1).String() != values[2].FieldByName("Request").Type().String() {
-		s.log.Debugw("wrong calling model", "subject", s.Subscription.Subject,
-			"wait", s.process.Type().In(1), "got", values[2].Type().String(),
-		)
+func (s *subscription) call(msg *nats.Msg) {
+	if msg.Header.Get(batchHeader) != "" {
+		s.callBatch(msg)
+		return
 	}
 
 	var (
-		start          = time.Now()
-		responseValues []reflect.Value
-		ctx            = createSession(values[2].FieldByName("Session").Interface().(SessionDTO))
-		err            error
+		start    = time.Now()
+		reqDTO   = newRequestDTO(s.process.Type().In(1))
+		dtoValue = newResponseDTO(s.process.Type().Out(0))
+		session  SessionDTO
+		procErr  error
+		err      error
 	)
 	defer func() {
+		s.metrics.ObserveHandler(s.Subscription.Subject, KindCall, handlerOutcome(procErr), time.Since(start))
 		s.log.Debugw("Call",
-			"subject", s.Subscription.Subject, "elapsed", time.Since(start).Seconds(),
-			"request", values[2].Field(1).Interface(), "response", responseValues[0].Interface(),
-			"error", responseValues[1].Interface(), "reply error", err,
+			"subject", s.Subscription.Subject, "elapsed", time.Since(start).Seconds(), "attempt", session.Attempt,
+			"request", s.redact.value(s.Subscription.Subject, reqDTO.FieldByName("Request").Interface()),
+			"response", s.redact.value(s.Subscription.Subject, dtoValue.FieldByName("Response").Interface()),
+			"error", procErr, "reply error", err,
 		)
 	}()
 
-	// calling the subscriber
-	responseValues = s.process.Call([]reflect.Value{reflect.ValueOf(ctx), values[2].FieldByName("Request")})
+	s.begin(KindCall)
+	defer s.end(KindCall)
+
+	// decoding the message, the client receives the decoding error as the process error
+	if procErr = s.codec.decode(msg.Data, reqDTO.Addr().Interface()); procErr == nil {
+		session = reqDTO.FieldByName("Session").Interface().(SessionDTO)
 
-	// creating structures for the response
-	dtoValue := newResponseDTO(responseValues[0].Type())
-	dtoValue.Field(0).Set(responseValues[0])
+		ctx, cancel := createSession(s.ctx, session, s.metadata)
+		defer cancel()
+
+		ctx, span := s.tracing.process(ctx, msg.Subject, trace.SpanKindServer, session)
+		defer func() { endSpan(span, procErr) }()
+
+		// the caller can cancel the call while the handler is running
+		if session.RequestID != nil {
+			defer s.cancels.register(*session.RequestID, cancel)()
+		}
+
+		// the caller's deadline has already passed or the call is canceled, the reply is not waited
+		if procErr = ctx.Err(); procErr != nil {
+			return
+		}
+
+		if procErr = s.admit(session, reqDTO.FieldByName("Request")); procErr == nil {
+			// calling the subscriber
+			procErr = s.handle(ctx, callInfo(ctx, msg.Subject, KindCall, session), reqDTO.FieldByName("Request"), dtoValue)
+
+			// the call is canceled by the caller, the reply is suppressed
+			if errors.Is(ctx.Err(), context.Canceled) {
+				procErr = ctx.Err()
+				return
+			}
+		}
+	}
 
 	// check process error
-	if !responseValues[1].IsZero() {
-		t := responseValues[1].Type().String()
-		m := responseValues[1].Interface().(error).Error()
-		var dto = ErrorDTO{
-			Type:    &t,
-			Message: &m,
+	if procErr != nil {
+		dtoValue.FieldByName("Error").Set(reflect.ValueOf(newErrorDTO(procErr)))
+	}
+
+	// reply to the client
+	err = s.response(msg.Reply, dtoValue.Addr().Interface())
+}
+
+// handle - invokes the call handler and sets the response of the DTO, returns the process error
+func (s *subscription) handle(ctx context.Context, info CallInfo, req reflect.Value, dtoValue reflect.Value) error {
+	resp, err := s.invoke(ctx, info, req)
+
+	// creating structures for the response, the response replaced by an interceptor must keep its type
+	if resp != nil {
+		var value = reflect.ValueOf(resp)
+		if value.Type() != dtoValue.FieldByName("Response").Type() {
+			return fmt.Errorf("invalid response of interceptor: %T", resp)
 		}
-		dtoValue.Field(1).Set(reflect.ValueOf(dto))
+		dtoValue.FieldByName("Response").Set(value)
 	}
 
-	// create error for reply
-	var respVal = s.response.Call(
-		[]reflect.Value{
-			values[1],
-			dtoValue.Addr(),
-		},
-	)
+	return err
+}
 
-	if !respVal[0].IsZero() {
-		err = respVal[0].Interface().(error)
+// admit - checks the call before the handler is invoked
+func (s *subscription) admit(session SessionDTO, req reflect.Value) error {
+	if err := validateFields(req.Interface()); err != nil {
+		return err
 	}
 
-	return nil
+	// the non-idempotent handler is called once, retries of the caller are rejected
+	return s.dedup.check(session.RequestID)
+}
+
+// tracker - counts in-flight handlers
+type tracker struct {
+	mux  sync.Mutex
+	n    int
+	idle chan struct{}
+}
+
+func (t *tracker) add() {
+	t.mux.Lock()
+	t.n++
+	t.mux.Unlock()
+}
+
+func (t *tracker) done() {
+	t.mux.Lock()
+	t.n--
+	if t.n == 0 && t.idle != nil {
+		close(t.idle)
+		t.idle = nil
+	}
+	t.mux.Unlock()
+}
+
+// wait - waits until there are no in-flight handlers or timeout expires
+func (t *tracker) wait(timeout time.Duration) {
+	t.mux.Lock()
+	if t.n == 0 {
+		t.mux.Unlock()
+		return
+	}
+	if t.idle == nil {
+		t.idle = make(chan struct{})
+	}
+	var idle = t.idle
+	t.mux.Unlock()
+
+	var timer = time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-idle:
+	case <-timer.C:
+	}
 }
@@ -0,0 +1,97 @@
+package client
+
+import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	tracerName = "github.com/LRichi/wcNATS/client"
+
+	// errorTypeKey - attribute of the handler error type from ErrorDTO
+	errorTypeKey = attribute.Key("wcnats.error.type")
+)
+
+// tracing - creates spans of calls and notifications, transfers the trace context in the session
+type tracing struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+// send - starts the span of outgoing message and injects its context to the session
+func (t tracing) send(ctx context.Context, subject string, kind trace.SpanKind, dto *SessionDTO) (context.Context, trace.Span) {
+	ctx, span := t.tracer.Start(ctx, subject+" send",
+		trace.WithSpanKind(kind),
+		trace.WithAttributes(messagingAttributes(subject, dto)...),
+	)
+
+	var carrier = propagation.MapCarrier{}
+	t.propagator.Inject(ctx, carrier)
+	if len(carrier) != 0 {
+		dto.Trace = carrier
+	}
+
+	return ctx, span
+}
+
+// process - extracts the trace context of the session and starts the span of incoming message
+func (t tracing) process(ctx context.Context, subject string, kind trace.SpanKind, dto SessionDTO) (context.Context, trace.Span) {
+	if len(dto.Trace) != 0 {
+		ctx = t.propagator.Extract(ctx, propagation.MapCarrier(dto.Trace))
+	}
+
+	return t.tracer.Start(ctx, subject+" process",
+		trace.WithSpanKind(kind),
+		trace.WithAttributes(append(messagingAttributes(subject, &dto), semconv.MessagingOperationProcess)...),
+	)
+}
+
+// endSpan - records the error and ends the span
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		var dto ErrorDTO
+		if errors.As(err, &dto) && dto.Type != nil {
+			span.SetAttributes(errorTypeKey.String(*dto.Type))
+		}
+
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
+
+func messagingAttributes(subject string, dto *SessionDTO) []attribute.KeyValue {
+	var attrs = []attribute.KeyValue{
+		semconv.MessagingSystemKey.String("nats"),
+		semconv.MessagingDestinationKey.String(subject),
+		semconv.MessagingDestinationKindTopic,
+	}
+	if dto.RequestID != nil {
+		attrs = append(attrs, semconv.MessagingMessageIDKey.String(*dto.RequestID))
+	}
+
+	return attrs
+}
+
+func newTracing(tp trace.TracerProvider, p propagation.TextMapPropagator) tracing {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+
+	if p == nil {
+		p = propagation.TraceContext{}
+	}
+
+	return tracing{
+		tracer:     tp.Tracer(tracerName),
+		propagator: p,
+	}
+}
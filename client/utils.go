@@ -3,6 +3,7 @@ package client
 import (
 	"fmt"
 	"reflect"
+	"strings"
 )
 
 const (
@@ -109,3 +110,29 @@ func validateHandleOfNotify(handle interface{}) error {
 
 	return nil
 }
+
+// matchSubject - checks the subject by the pattern with NATS wildcards '*' and '>'
+func matchSubject(pattern, subject string) bool {
+	var (
+		pt = strings.Split(pattern, ".")
+		st = strings.Split(subject, ".")
+	)
+
+	for i, p := range pt {
+		switch {
+		case p == ">":
+			return len(st) > i
+		case i >= len(st):
+			return false
+		case p != "*" && p != st[i]:
+			return false
+		}
+	}
+
+	return len(pt) == len(st)
+}
+
+// validQueueName - checks the name of the queue group, it must not be empty or contain whitespaces
+func validQueueName(queue string) bool {
+	return queue != "" && !strings.ContainsAny(queue, " \t\r\n")
+}
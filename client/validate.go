@@ -0,0 +1,274 @@
+package client
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// validateTagName - struct tag of validation rules of fields:
+//
+//	`validate:"required,min=1,max=64,enum=new|paid,regex=^[a-z]+$"`
+//
+// min and max limit numbers or the length of strings, slices and maps, regex must be the last rule
+const validateTagName = "validate"
+
+// FieldViolation - the field of the request or response violates the rule
+type FieldViolation struct {
+	// Field - path of the field with JSON names, e.g. Items[1].Name
+	Field string
+	// Rule - violated rule of the tag, e.g. min=1
+	Rule string
+}
+
+// InvalidArgument - the request or response violates validation rules, the caller receives it
+// with the code CodeInvalidArgument
+type InvalidArgument struct {
+	Violations []FieldViolation
+}
+
+func (e *InvalidArgument) Error() string {
+	var parts = make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		parts = append(parts, v.Field+": "+v.Rule)
+	}
+
+	return "invalid argument: " + strings.Join(parts, ", ")
+}
+
+// StatusCode - returns CodeInvalidArgument
+func (e *InvalidArgument) StatusCode() Code {
+	return CodeInvalidArgument
+}
+
+func init() {
+	RegisterErrorType("wcnats.InvalidArgument", &InvalidArgument{})
+}
+
+// fieldRule - the parsed rule of the field
+type fieldRule struct {
+	name  string
+	tag   string
+	num   float64
+	enum  []string
+	regex *regexp.Regexp
+}
+
+// fieldRules - rules of the field of the struct
+type fieldRules struct {
+	index int
+	name  string
+	rules []fieldRule
+}
+
+var validateCache sync.Map // reflect.Type -> []fieldRules
+
+// validateFields - checks the model by tags of its fields, returns *InvalidArgument with all violations
+func validateFields(model interface{}) error {
+	var violations []FieldViolation
+	if err := validateValue(reflect.ValueOf(model), "", &violations, 0); err != nil {
+		return err
+	}
+
+	if len(violations) != 0 {
+		return &InvalidArgument{Violations: violations}
+	}
+
+	return nil
+}
+
+// validateValue - checks fields of structs in the value recursively
+func validateValue(v reflect.Value, path string, violations *[]FieldViolation, depth int) error {
+	if depth > maxRedactDepth {
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return validateValue(v.Elem(), path, violations, depth+1)
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return nil
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := validateValue(v.Index(i), path+"["+strconv.Itoa(i)+"]", violations, depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Struct:
+	default:
+		return nil
+	}
+
+	fields, err := rulesOf(v.Type())
+	if err != nil {
+		return err
+	}
+
+	for _, f := range fields {
+		var (
+			fv        = v.Field(f.index)
+			fieldPath = f.name
+		)
+		if path != "" {
+			fieldPath = path + "." + f.name
+		}
+
+		for _, r := range f.rules {
+			if !r.check(fv) {
+				*violations = append(*violations, FieldViolation{Field: fieldPath, Rule: r.tag})
+			}
+		}
+
+		if err = validateValue(fv, fieldPath, violations, depth+1); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// rulesOf - returns parsed rules of exported fields of the struct type
+func rulesOf(t reflect.Type) ([]fieldRules, error) {
+	if cached, ok := validateCache.Load(t); ok {
+		return cached.([]fieldRules), nil
+	}
+
+	var fields []fieldRules
+	for i := 0; i < t.NumField(); i++ {
+		var f = t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+
+		name, skip := fieldName(f)
+		if skip {
+			continue
+		}
+
+		rules, err := parseRules(f.Tag.Get(validateTagName))
+		if err != nil {
+			return nil, fmt.Errorf("invalid tag of %s.%s: %w", t.String(), f.Name, err)
+		}
+
+		fields = append(fields, fieldRules{index: i, name: name, rules: rules})
+	}
+
+	validateCache.Store(t, fields)
+
+	return fields, nil
+}
+
+// parseRules - parses comma separated rules of the tag, the regex takes the rest of the tag
+func parseRules(tag string) (rules []fieldRule, err error) {
+	for tag != "" {
+		var part string
+		if strings.HasPrefix(tag, "regex=") {
+			part, tag = tag, ""
+		} else if i := strings.IndexByte(tag, ','); i >= 0 {
+			part, tag = tag[:i], tag[i+1:]
+		} else {
+			part, tag = tag, ""
+		}
+
+		var (
+			r        = fieldRule{tag: part}
+			kv       = strings.SplitN(part, "=", 2)
+			hasValue = len(kv) == 2
+			value    string
+		)
+		if r.name = kv[0]; hasValue {
+			value = kv[1]
+		}
+
+		switch {
+		case r.name == "required" && !hasValue:
+		case (r.name == "min" || r.name == "max") && hasValue:
+			if r.num, err = strconv.ParseFloat(value, 64); err != nil {
+				return nil, fmt.Errorf("rule %s: %w", part, err)
+			}
+		case r.name == "enum" && hasValue:
+			r.enum = strings.Split(value, "|")
+		case r.name == "regex" && hasValue:
+			if r.regex, err = regexp.Compile(value); err != nil {
+				return nil, fmt.Errorf("rule %s: %w", part, err)
+			}
+		case r.name == "":
+			continue
+		default:
+			return nil, fmt.Errorf("unknown rule %s", part)
+		}
+
+		rules = append(rules, r)
+	}
+
+	return rules, nil
+}
+
+// check - checks the value of the field by the rule
+func (r fieldRule) check(v reflect.Value) bool {
+	switch r.name {
+	case "required":
+		if v.Kind() == reflect.Slice || v.Kind() == reflect.Map {
+			return v.Len() != 0
+		}
+		return !v.IsZero()
+	case "min":
+		n, ok := measure(v)
+		return !ok || n >= r.num
+	case "max":
+		n, ok := measure(v)
+		return !ok || n <= r.num
+	case "enum":
+		if isNilValue(v) {
+			return true
+		}
+		var s = fmt.Sprint(reflect.Indirect(v).Interface())
+		for _, e := range r.enum {
+			if s == e {
+				return true
+			}
+		}
+		return false
+	case "regex":
+		if v = reflect.Indirect(v); v.Kind() != reflect.String {
+			return true
+		}
+		return r.regex.MatchString(v.String())
+	default:
+		return true
+	}
+}
+
+// measure - returns the number or the length of the value, optional nil values are not measured
+func measure(v reflect.Value) (float64, bool) {
+	if isNilValue(v) {
+		return 0, false
+	}
+
+	switch v = reflect.Indirect(v); v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	case reflect.String:
+		return float64(len([]rune(v.String()))), true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(v.Len()), true
+	default:
+		return 0, false
+	}
+}
+
+func isNilValue(v reflect.Value) bool {
+	return v.Kind() == reflect.Ptr && v.IsNil()
+}
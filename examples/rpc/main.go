@@ -24,7 +24,7 @@ type right struct {
 }
 
 func (r *right) receiveCall(ctx context.Context, req *Request) (*Response, error) {
-	r.log.Infow("received call", "session", ctx.Value("session"), "request", req)
+	r.log.Infow("received call", "session", client.SessionFrom(ctx).ID, "request", req)
 
 	if req.Message == "" {
 		return &Response{}, fmt.Errorf("no messge")
@@ -34,7 +34,7 @@ func (r *right) receiveCall(ctx context.Context, req *Request) (*Response, error
 }
 
 func (r *right) receiveNotify(ctx context.Context, req *Request) error {
-	r.log.Infow("received notify", "session", ctx.Value("session"), "request", req)
+	r.log.Infow("received notify", "session", client.SessionFrom(ctx).ID, "request", req)
 
 	// if use return error, search in NATS client debug
 	if req.Message == "" {
@@ -83,7 +83,7 @@ func main() {
 	}
 
 	var (
-		ctx = context.Background()
+		ctx = client.WithSession(context.Background(), "example")
 		cli = client.New(log.Sugar().Named("CLIENT"), "127.0.0.1:4222", "test", 100)
 	)
 	defer cli.Close()
@@ -0,0 +1,10 @@
+package logger
+
+import (
+	"github.com/LRichi/wcNATS/client"
+)
+
+// Nop - returns logger of the client which discards all entries
+func Nop() client.Logger {
+	return client.NopLogger()
+}
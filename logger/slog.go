@@ -0,0 +1,34 @@
+//go:build go1.21
+
+package logger
+
+import (
+	"log/slog"
+
+	"github.com/LRichi/wcNATS/client"
+)
+
+type slogLogger struct {
+	l *slog.Logger
+}
+
+func (s slogLogger) Debugw(msg string, keysAndValues ...interface{}) {
+	s.l.Debug(msg, keysAndValues...)
+}
+
+func (s slogLogger) Infow(msg string, keysAndValues ...interface{}) {
+	s.l.Info(msg, keysAndValues...)
+}
+
+func (s slogLogger) Warnw(msg string, keysAndValues ...interface{}) {
+	s.l.Warn(msg, keysAndValues...)
+}
+
+func (s slogLogger) Errorw(msg string, keysAndValues ...interface{}) {
+	s.l.Error(msg, keysAndValues...)
+}
+
+// Slog - returns logger of the client writing to log/slog logger
+func Slog(l *slog.Logger) client.Logger {
+	return slogLogger{l: l}
+}
@@ -0,0 +1,12 @@
+package logger
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/LRichi/wcNATS/client"
+)
+
+// Zap - returns logger of the client writing to zap logger
+func Zap(l *zap.Logger) client.Logger {
+	return l.Sugar()
+}
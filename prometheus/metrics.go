@@ -0,0 +1,122 @@
+package prometheus
+
+import (
+	"time"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+
+	"github.com/LRichi/wcNATS/client"
+)
+
+// Metrics - exports measurements of the client to Prometheus
+type Metrics struct {
+	requests  *prom.HistogramVec
+	retries   *prom.CounterVec
+	hedges    *prom.CounterVec
+	publishes *prom.CounterVec
+	handlers  *prom.HistogramVec
+	inFlight  *prom.GaugeVec
+	pending   *prom.GaugeVec
+}
+
+// ObserveRequest - implements client.Metrics
+func (m *Metrics) ObserveRequest(subject string, outcome client.Outcome, elapsed time.Duration) {
+	m.requests.WithLabelValues(subject, string(outcome)).Observe(elapsed.Seconds())
+}
+
+// ObserveRetry - implements client.RetryMetrics
+func (m *Metrics) ObserveRetry(subject string, _ int, outcome client.Outcome) {
+	m.retries.WithLabelValues(subject, string(outcome)).Inc()
+}
+
+// ObserveHedge - implements client.HedgeMetrics
+func (m *Metrics) ObserveHedge(subject string, _ int, winner int) {
+	var result = "hedge"
+	switch {
+	case winner == 0:
+		result = "original"
+	case winner < 0:
+		result = "failed"
+	}
+	m.hedges.WithLabelValues(subject, result).Inc()
+}
+
+// ObservePublish - implements client.Metrics
+func (m *Metrics) ObservePublish(subject string, outcome client.Outcome) {
+	m.publishes.WithLabelValues(subject, string(outcome)).Inc()
+}
+
+// ObserveHandler - implements client.Metrics
+func (m *Metrics) ObserveHandler(subject string, kind client.HandlerKind, outcome client.Outcome, elapsed time.Duration) {
+	m.handlers.WithLabelValues(subject, string(kind), string(outcome)).Observe(elapsed.Seconds())
+}
+
+// AddInFlight - implements client.Metrics
+func (m *Metrics) AddInFlight(subject string, kind client.HandlerKind, delta int) {
+	m.inFlight.WithLabelValues(subject, string(kind)).Add(float64(delta))
+}
+
+// SetPending - implements client.Metrics
+func (m *Metrics) SetPending(subject string, msgs int) {
+	m.pending.WithLabelValues(subject).Set(float64(msgs))
+}
+
+// New - creates metrics of the client and registers them in the registerer
+//
+// the counts of calls and handlers by outcome are the _count series of the histograms
+func New(reg prom.Registerer, namespace string) (*Metrics, error) {
+	var m = &Metrics{
+		requests: prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "wcnats",
+			Name:      "request_duration_seconds",
+			Help:      "Latency of remote procedure calls by subject and outcome.",
+			Buckets:   prom.DefBuckets,
+		}, []string{"subject", "outcome"}),
+		retries: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "wcnats",
+			Name:      "request_retries_total",
+			Help:      "Number of retried attempts of remote procedure calls by subject and outcome of the attempt.",
+		}, []string{"subject", "outcome"}),
+		hedges: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "wcnats",
+			Name:      "request_hedges_total",
+			Help:      "Number of hedged remote procedure calls by subject and the copy which won (original, hedge, failed).",
+		}, []string{"subject", "result"}),
+		publishes: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "wcnats",
+			Name:      "publish_total",
+			Help:      "Number of published notifications by subject and outcome.",
+		}, []string{"subject", "outcome"}),
+		handlers: prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "wcnats",
+			Name:      "handler_duration_seconds",
+			Help:      "Duration of subscription handlers by subject, kind and outcome.",
+			Buckets:   prom.DefBuckets,
+		}, []string{"subject", "kind", "outcome"}),
+		inFlight: prom.NewGaugeVec(prom.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "wcnats",
+			Name:      "handlers_in_flight",
+			Help:      "Number of running subscription handlers by subject and kind.",
+		}, []string{"subject", "kind"}),
+		pending: prom.NewGaugeVec(prom.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "wcnats",
+			Name:      "subscription_pending_messages",
+			Help:      "Number of messages waiting for processing by subscription subject.",
+		}, []string{"subject"}),
+	}
+
+	for _, c := range []prom.Collector{m.requests, m.retries, m.hedges, m.publishes, m.handlers, m.inFlight, m.pending} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
@@ -0,0 +1,177 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/LRichi/wcNATS/client"
+)
+
+const subjectAsync = "test.subject.async"
+
+func TestRequestAsync(t *testing.T) {
+	var (
+		cli      = client.New(nil, "127.0.0.1:1222", "test", 100)
+		canceled = make(chan error, 1)
+	)
+	defer cli.Close()
+
+	sub, err := cli.Subscribe(subjectAsync, func(ctx context.Context, req *Request) (*Response, error) {
+		if req.Message == "slow" {
+			<-ctx.Done()
+			canceled <- ctx.Err()
+			return nil, ctx.Err()
+		}
+		return &Response{Message: "echo " + req.Message}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = cli.Unsubscribe(sub) }()
+
+	t.Run("TEST_FUTURES", func(t *testing.T) {
+		var (
+			futures   = make([]*client.Future, 20)
+			responses = make([]Response, len(futures))
+		)
+		for i := range futures {
+			futures[i] = cli.RequestAsync(context.Background(), subjectAsync, &Request{Message: fmt.Sprint(i)}, &responses[i])
+		}
+
+		for i, f := range futures {
+			if err := f.Wait(); err != nil {
+				t.Fatal(err)
+			}
+			if want := fmt.Sprint("echo ", i); responses[i].Message != want || f.Response().(*Response).Message != want {
+				t.Errorf("response %d = %q, want %q", i, responses[i].Message, want)
+			}
+		}
+	})
+
+	t.Run("TEST_CALLBACK", func(t *testing.T) {
+		var (
+			wg   sync.WaitGroup
+			mux  sync.Mutex
+			errs []error
+		)
+		wg.Add(10)
+		for i := 0; i < 10; i++ {
+			cli.RequestAsyncFunc(context.Background(), subjectAsync, &Request{Message: "cb"}, &Response{}, func(err error) {
+				mux.Lock()
+				errs = append(errs, err)
+				mux.Unlock()
+				wg.Done()
+			})
+		}
+		wg.Wait()
+
+		for _, err := range errs {
+			if err != nil {
+				t.Error(err)
+			}
+		}
+	})
+
+	t.Run("TEST_DEADLINE", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		var f = cli.RequestAsync(ctx, subjectAsync, &Request{Message: "slow"}, &Response{})
+		select {
+		case <-f.Done():
+		case <-time.After(time.Second):
+			t.Fatal("the future is not completed by the deadline")
+		}
+		if !errors.Is(f.Err(), context.DeadlineExceeded) {
+			t.Errorf("error = %v, want deadline exceeded", f.Err())
+		}
+		<-canceled
+	})
+
+	t.Run("TEST_CANCEL", func(t *testing.T) {
+		var f = cli.RequestAsync(context.Background(), subjectAsync, &Request{Message: "slow"}, &Response{})
+		time.Sleep(50 * time.Millisecond)
+		f.Cancel()
+
+		if err := f.Wait(); !errors.Is(err, context.Canceled) {
+			t.Errorf("error = %v, want canceled", err)
+		}
+
+		select {
+		case err := <-canceled:
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("handler context error = %v, want canceled", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("the remote handler is not canceled")
+		}
+	})
+
+	t.Run("TEST_NO_RESPONDERS", func(t *testing.T) {
+		err := cli.RequestAsync(context.Background(), subjectAsync+".nobody", &Request{}, &Response{}).Wait()
+		if !errors.As(err, &client.NoResponders{}) {
+			t.Errorf("error %#v is not NoResponders", err)
+		}
+	})
+}
+
+func TestRequestAsyncExpiry(t *testing.T) {
+	var (
+		cli     = client.New(nil, "127.0.0.1:1222", "test", 100, client.WithAsyncTimeout(200*time.Millisecond))
+		subject = subjectAsync + ".silent"
+	)
+	defer cli.Close()
+
+	// the notify handler receives calls without replying
+	sub, err := cli.Subscribe(subject, func(ctx context.Context, req *Request) error {
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = cli.Unsubscribe(sub) }()
+
+	// futures do not start goroutines, the deadline and the timeout of the client complete them
+	var (
+		base    = runtime.NumGoroutine()
+		futures = make([]*client.Future, 0, 300)
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	for i := 0; i < 200; i++ {
+		futures = append(futures, cli.RequestAsync(ctx, subject, &Request{}, &Response{}))
+	}
+	for i := 0; i < 100; i++ {
+		futures = append(futures, cli.RequestAsync(context.Background(), subject, &Request{}, &Response{}))
+	}
+
+	if n := runtime.NumGoroutine() - base; n > 10 {
+		t.Errorf("goroutines of futures = %d, want at most 10", n)
+	}
+	if n := cli.PendingAsync(); n != len(futures) {
+		t.Errorf("pending futures = %d, want %d", n, len(futures))
+	}
+
+	for i, f := range futures {
+		select {
+		case <-f.Done():
+		case <-time.After(time.Second):
+			t.Fatalf("future %d is not expired", i)
+		}
+
+		if i < 200 && !errors.Is(f.Err(), context.DeadlineExceeded) {
+			t.Errorf("error %d = %v, want deadline exceeded", i, f.Err())
+		} else if i >= 200 && !errors.As(f.Err(), &client.Timeout{}) {
+			t.Errorf("error %d = %v, want Timeout", i, f.Err())
+		}
+	}
+
+	if n := cli.PendingAsync(); n != 0 {
+		t.Errorf("pending futures = %d after expiry, want 0", n)
+	}
+}
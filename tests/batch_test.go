@@ -0,0 +1,133 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/LRichi/wcNATS/client"
+)
+
+const subjectBatch = "test.subject.batch"
+
+func TestRequestBatch(t *testing.T) {
+	var (
+		cli           = client.New(nil, "127.0.0.1:1222", "test", 100)
+		errItem       = errors.New("bad item")
+		concurrency   = 3
+		running, peak int32
+	)
+	defer cli.Close()
+
+	sub, err := cli.Subscribe(subjectBatch, func(ctx context.Context, req *Request) (*Response, error) {
+		var n = atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			var p = atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		if req.Message == "bad" {
+			return nil, errItem
+		}
+		return &Response{Message: "echo " + req.Message}, nil
+	}, client.SubBatchConcurrency(concurrency))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = cli.Unsubscribe(sub) }()
+
+	t.Run("TEST_ITEMS", func(t *testing.T) {
+		var (
+			requests  = make([]*Request, 10)
+			responses []*Response
+		)
+		for i := range requests {
+			requests[i] = &Request{Message: fmt.Sprint(i)}
+		}
+		requests[4].Message = "bad"
+
+		errs, err := cli.RequestBatch(context.Background(), subjectBatch, requests, &responses)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(responses) != len(requests) || len(errs) != len(requests) {
+			t.Fatalf("got %d responses and %d errors for %d requests", len(responses), len(errs), len(requests))
+		}
+
+		for i, resp := range responses {
+			if i == 4 {
+				if errs[i] == nil || errs[i].Error() != errItem.Error() {
+					t.Errorf("error %d = %v, want %v", i, errs[i], errItem)
+				}
+				continue
+			}
+			if errs[i] != nil {
+				t.Errorf("error %d = %v", i, errs[i])
+			}
+			if want := fmt.Sprint("echo ", i); resp.Message != want {
+				t.Errorf("response %d = %q, want %q", i, resp.Message, want)
+			}
+		}
+
+		if p := atomic.LoadInt32(&peak); p < 2 || p > int32(concurrency) {
+			t.Errorf("peak concurrency = %d, want 2..%d", p, concurrency)
+		}
+	})
+
+	t.Run("TEST_SINGLE_REQUEST", func(t *testing.T) {
+		var resp Response
+		if err := cli.Request(context.Background(), subjectBatch, &Request{Message: "one"}, &resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Message != "echo one" {
+			t.Errorf("response = %q", resp.Message)
+		}
+	})
+
+	t.Run("TEST_INVALID", func(t *testing.T) {
+		var responses []*Response
+		if _, err := cli.RequestBatch(context.Background(), subjectBatch, []*Request{nil}, &responses); err == nil {
+			t.Error("nil item is sent")
+		}
+		if _, err := cli.RequestBatch(context.Background(), subjectBatch, &Request{}, &responses); err == nil {
+			t.Error("request which is not a slice is sent")
+		}
+	})
+}
+
+func TestRequestBatchDefaultConcurrency(t *testing.T) {
+	var cli = client.New(nil, "127.0.0.1:1222", "test", 100)
+	defer cli.Close()
+
+	// invalid sizes fall back to the default, the subscription keeps handling calls
+	for i, n := range []int{0, -1} {
+		var subject = fmt.Sprint(subjectBatch, ".default.", i)
+		sub, err := cli.Subscribe(subject, func(ctx context.Context, req *Request) (*Response, error) {
+			return &Response{Message: "echo " + req.Message}, nil
+		}, client.SubBatchConcurrency(n))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		var responses []*Response
+		errs, err := cli.RequestBatch(ctx, subject, []*Request{{Message: "a"}, {Message: "b"}}, &responses)
+		if err != nil || len(responses) != 2 || errs[0] != nil || errs[1] != nil {
+			t.Errorf("concurrency %d: responses = %d, errors = %v, error = %v", n, len(responses), errs, err)
+		}
+
+		var resp Response
+		if err = cli.Request(ctx, subject, &Request{Message: "one"}, &resp); err != nil || resp.Message != "echo one" {
+			t.Errorf("concurrency %d: response = %q, error = %v", n, resp.Message, err)
+		}
+		cancel()
+		_ = cli.Unsubscribe(sub)
+	}
+}
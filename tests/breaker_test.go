@@ -0,0 +1,92 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/LRichi/wcNATS/client"
+)
+
+const subjectBreaker = "test.subject.breaker"
+
+func TestCircuitBreaker(t *testing.T) {
+	var (
+		mux     sync.Mutex
+		changes []string
+		cli     = client.New(nil, "127.0.0.1:1222", "test", 100,
+			client.WithCircuitBreaker(subjectBreaker+".*", client.BreakerPolicy{
+				Thresholds:  map[client.Outcome]int{client.OutcomeNoResponders: 2},
+				OpenTimeout: 100 * time.Millisecond,
+			}),
+			client.WithHooks(client.Hooks{OnCircuitChange: func(subject string, from, to client.CircuitState) {
+				mux.Lock()
+				changes = append(changes, string(from)+">"+string(to))
+				mux.Unlock()
+			}}),
+		)
+	)
+	defer cli.Close()
+
+	var call = func(subject string) error {
+		return cli.Request(context.Background(), subject, &Request{}, &Response{})
+	}
+
+	t.Run("TEST_OPEN_AND_CLOSE", func(t *testing.T) {
+		var subject = subjectBreaker + ".down"
+		for i := 0; i < 2; i++ {
+			if err := call(subject); !errors.As(err, &client.NoResponders{}) {
+				t.Fatalf("error = %v, want NoResponders", err)
+			}
+		}
+
+		var open *client.CircuitOpen
+		if err := call(subject); !errors.As(err, &open) || open.RetryAfter <= 0 {
+			t.Fatalf("error = %v, want CircuitOpen", err)
+		}
+
+		// the service is back, the trial call closes the circuit
+		sub, err := cli.Subscribe(subject, func(ctx context.Context, req *Request) (*Response, error) {
+			return &Response{}, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = cli.Unsubscribe(sub) }()
+
+		time.Sleep(120 * time.Millisecond)
+		for i := 0; i < 3; i++ {
+			if err := call(subject); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		mux.Lock()
+		defer mux.Unlock()
+		var want = []string{"closed>open", "open>half_open", "half_open>closed"}
+		if !reflect.DeepEqual(changes, want) {
+			t.Errorf("changes = %v, want %v", changes, want)
+		}
+	})
+
+	t.Run("TEST_HANDLER_ERRORS", func(t *testing.T) {
+		var subject = subjectBreaker + ".errors"
+		sub, err := cli.Subscribe(subject, func(ctx context.Context, req *Request) (*Response, error) {
+			return nil, errors.New("not found")
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = cli.Unsubscribe(sub) }()
+
+		for i := 0; i < 5; i++ {
+			var open *client.CircuitOpen
+			if err := call(subject); err == nil || errors.As(err, &open) {
+				t.Fatalf("error = %v, want the error of the handler", err)
+			}
+		}
+	})
+}
@@ -0,0 +1,131 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/LRichi/wcNATS/client"
+)
+
+const subjectCancel = "test.subject.cancel"
+
+func TestCancel_Request(t *testing.T) {
+	lc := zap.NewDevelopmentConfig()
+	lc.DisableStacktrace = true
+	lc.DisableCaller = true
+
+	log, err := lc.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		cli      = client.New(log.Sugar().Named("NATS").Named("CLIENT"), "127.0.0.1:1222", "test", 100)
+		canceled = make(chan error, 1)
+	)
+	defer cli.Close()
+
+	sub, err := cli.Subscribe(subjectCancel, func(ctx context.Context, req *Request) (*Response, error) {
+		select {
+		case <-time.After(5 * time.Second):
+			canceled <- nil
+			return &Response{Message: "done"}, nil
+		case <-ctx.Done():
+			canceled <- ctx.Err()
+			return nil, ctx.Err()
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = cli.Unsubscribe(sub) }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	if err = cli.Request(ctx, subjectCancel, &Request{Message: "long"}, &Response{}); err == nil {
+		t.Fatal("expected canceled error")
+	}
+
+	select {
+	case err = <-canceled:
+		if err != context.Canceled {
+			t.Errorf("handler error = %v, want %v", err, context.Canceled)
+		}
+	case <-time.After(time.Second):
+		t.Error("handler is not canceled")
+	}
+}
+
+func TestCancel_Unsubscribe(t *testing.T) {
+	lc := zap.NewDevelopmentConfig()
+	lc.DisableStacktrace = true
+	lc.DisableCaller = true
+
+	log, err := lc.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cli = client.New(log.Sugar().Named("NATS").Named("CLIENT"), "127.0.0.1:1222", "test", 100,
+		client.WithGracePeriod(100*time.Millisecond),
+	)
+	defer cli.Close()
+
+	tests := []struct {
+		name string
+		stop func(sub client.Subscription) error
+	}{
+		{
+			name: "TEST_UNSUBSCRIBE",
+			stop: cli.Unsubscribe,
+		},
+		{
+			name: "TEST_DRAIN",
+			stop: cli.Drain,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				started  = make(chan struct{})
+				canceled = make(chan error, 1)
+			)
+
+			sub, err := cli.Subscribe(subjectCancel, func(ctx context.Context, req *Request) error {
+				close(started)
+				select {
+				case <-time.After(5 * time.Second):
+					canceled <- nil
+				case <-ctx.Done():
+					canceled <- ctx.Err()
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err = cli.Publish(context.Background(), subjectCancel, &Request{Message: "long"}); err != nil {
+				t.Fatal(err)
+			}
+			<-started
+
+			if err = tt.stop(sub); err != nil {
+				t.Fatal(err)
+			}
+
+			select {
+			case err = <-canceled:
+				if err != context.Canceled {
+					t.Errorf("handler error = %v, want %v", err, context.Canceled)
+				}
+			case <-time.After(time.Second):
+				t.Error("handler is not canceled")
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/LRichi/wcNATS/client"
+)
+
+const subjectDeadline = "test.subject.deadline"
+
+func TestDeadline_Request(t *testing.T) {
+	lc := zap.NewDevelopmentConfig()
+	lc.DisableStacktrace = true
+	lc.DisableCaller = true
+
+	log, err := lc.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		cli   = client.New(log.Sugar().Named("NATS").Named("CLIENT"), "127.0.0.1:1222", "test", 100)
+		calls int32
+	)
+	defer cli.Close()
+
+	sub, err := cli.Subscribe(subjectDeadline, func(ctx context.Context, req *Request) (*Response, error) {
+		atomic.AddInt32(&calls, 1)
+		if _, ok := ctx.Deadline(); !ok {
+			return nil, errors.New("no deadline")
+		}
+
+		d, err := time.ParseDuration(req.Message)
+		if err != nil {
+			return nil, err
+		}
+
+		select {
+		case <-time.After(d):
+			return &Response{Message: "done"}, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = cli.Unsubscribe(sub) }()
+
+	t.Run("TEST_DEADLINE_RECEIVED", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		var resp Response
+		if err = cli.Request(ctx, subjectDeadline, &Request{Message: "1ms"}, &resp); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("TEST_EXPIRED_ON_ARRIVAL", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 0)
+
+		// the first call holds the subscription, the second one waits in the queue until its deadline
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			_ = cli.Request(ctx, subjectDeadline, &Request{Message: "200ms"}, &Response{})
+		}()
+		time.Sleep(50 * time.Millisecond)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		if err = cli.Request(ctx, subjectDeadline, &Request{Message: "1ms"}, &Response{}); err == nil {
+			t.Fatal("expected deadline error")
+		}
+
+		time.Sleep(300 * time.Millisecond)
+		if n := atomic.LoadInt32(&calls); n != 1 {
+			t.Errorf("handler calls = %d, want 1", n)
+		}
+	})
+}
+
+func TestDeadline_Notify(t *testing.T) {
+	var (
+		cli     = client.New(nil, "127.0.0.1:1222", "test", 100)
+		handled = make(chan bool, 2)
+	)
+	defer cli.Close()
+
+	// the second notification waits in the queue longer than the publisher's deadline
+	sub, err := cli.Subscribe(subjectDeadline+".notify", func(ctx context.Context, req *Request) error {
+		time.Sleep(100 * time.Millisecond)
+		_, hasDeadline := ctx.Deadline()
+		handled <- hasDeadline
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = cli.Unsubscribe(sub) }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	for i := 0; i < 2; i++ {
+		if err = cli.Publish(ctx, subjectDeadline+".notify", &Request{Message: "queued"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i := 0; i < 2; i++ {
+		select {
+		case hasDeadline := <-handled:
+			if hasDeadline {
+				t.Error("the handler context has the publisher's deadline")
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("handled %d notifications, want 2", i)
+		}
+	}
+}
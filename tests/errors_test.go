@@ -0,0 +1,294 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"go.uber.org/zap"
+
+	"github.com/LRichi/wcNATS/client"
+)
+
+const subjectErrors = "test.subject.errors"
+
+var ErrItemNotFound = errors.New("item not found")
+
+type QuotaError struct {
+	Limit int
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("quota %d exceeded", e.Limit)
+}
+
+func (e *QuotaError) StatusCode() client.Code {
+	return client.CodeResourceExhausted
+}
+
+func init() {
+	client.RegisterError("tests.ItemNotFound", ErrItemNotFound)
+	client.RegisterErrorType("tests.QuotaError", &QuotaError{})
+}
+
+func TestErrors_Remote(t *testing.T) {
+	lc := zap.NewDevelopmentConfig()
+	lc.DisableStacktrace = true
+	lc.DisableCaller = true
+
+	log, err := lc.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cli = client.New(log.Sugar().Named("NATS").Named("CLIENT"), "127.0.0.1:1222", "test", 100)
+	defer cli.Close()
+
+	sub, err := cli.Subscribe(subjectErrors, func(ctx context.Context, req *Request) (*Response, error) {
+		switch req.Message {
+		case "sentinel":
+			return nil, fmt.Errorf("lookup: %w", ErrItemNotFound)
+		case "type":
+			return nil, &QuotaError{Limit: 10}
+		case "code":
+			return nil, client.Errorf(client.CodePermissionDenied, "access denied")
+		default:
+			return nil, errors.New("unknown")
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = cli.Unsubscribe(sub) }()
+
+	tests := []struct {
+		name  string
+		req   string
+		code  client.Code
+		check func(err error) bool
+	}{
+		{
+			name:  "TEST_SENTINEL",
+			req:   "sentinel",
+			code:  client.CodeUnknown,
+			check: func(err error) bool { return errors.Is(err, ErrItemNotFound) },
+		},
+		{
+			name: "TEST_TYPE",
+			req:  "type",
+			code: client.CodeResourceExhausted,
+			check: func(err error) bool {
+				var qe *QuotaError
+				return errors.As(err, &qe) && qe.Limit == 10
+			},
+		},
+		{
+			name:  "TEST_CODE",
+			req:   "code",
+			code:  client.CodePermissionDenied,
+			check: func(err error) bool { return err.Error() == "access denied" },
+		},
+		{
+			name: "TEST_UNREGISTERED",
+			req:  "other",
+			code: client.CodeUnknown,
+			check: func(err error) bool {
+				var dto client.ErrorDTO
+				return errors.As(err, &dto) && *dto.Type == "*errors.errorString"
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := cli.Request(context.Background(), subjectErrors, &Request{Message: tt.req}, &Response{})
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if code := client.CodeOf(err); code != tt.code {
+				t.Errorf("code = %s, want %s", code, tt.code)
+			}
+			if !tt.check(err) {
+				t.Errorf("unexpected error %#v", err)
+			}
+		})
+	}
+}
+
+func TestErrors_NATS(t *testing.T) {
+	var cli = client.New(nil, "127.0.0.1:1222", "test", 100)
+	defer cli.Close()
+
+	err := cli.Request(context.Background(), subjectErrors+".nobody", &Request{Message: "hi"}, &Response{})
+
+	var nr client.NoResponders
+	switch {
+	case !errors.As(err, &nr):
+		t.Errorf("error %#v is not NoResponders", err)
+	case !errors.Is(err, nats.ErrNoResponders):
+		t.Errorf("error %#v is not nats.ErrNoResponders", err)
+	case !client.IsRetryable(err) || !nr.Temporary():
+		t.Errorf("error %#v is not retryable", err)
+	}
+}
+
+func TestErrors_Panic(t *testing.T) {
+	var (
+		panics = make(chan interface{}, 1)
+		cli    = client.New(nil, "127.0.0.1:1222", "test", 100, client.WithHooks(client.Hooks{
+			OnPanic: func(subject string, recovered interface{}, stack []byte) { panics <- recovered },
+		}))
+	)
+	defer cli.Close()
+
+	sub, err := cli.Subscribe(subjectErrors+".panic", func(ctx context.Context, req *Request) (*Response, error) {
+		if req.Message == "panic" {
+			panic("boom")
+		}
+		return &Response{Message: "alive"}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = cli.Unsubscribe(sub) }()
+
+	err = cli.Request(context.Background(), subjectErrors+".panic", &Request{Message: "panic"}, &Response{})
+
+	var pe *client.PanicError
+	switch {
+	case !errors.As(err, &pe):
+		t.Fatalf("error %#v is not PanicError", err)
+	case pe.Value != "boom":
+		t.Errorf("panic value = %q, want %q", pe.Value, "boom")
+	case client.CodeOf(err) != client.CodeInternal:
+		t.Errorf("code = %s, want %s", client.CodeOf(err), client.CodeInternal)
+	}
+
+	if recovered := <-panics; recovered != "boom" {
+		t.Errorf("hook recovered = %v, want boom", recovered)
+	}
+
+	// the subscription keeps working
+	var resp Response
+	if err = cli.Request(context.Background(), subjectErrors+".panic", &Request{Message: "ping"}, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Message != "alive" {
+		t.Errorf("response = %q, want alive", resp.Message)
+	}
+
+	// the panic of the notify handler is recovered too
+	var delivered = make(chan string, 1)
+	notify, err := cli.Subscribe(subjectErrors+".panic.notify", func(ctx context.Context, req *Request) error {
+		if req.Message == "panic" {
+			panic("boom")
+		}
+		delivered <- req.Message
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = cli.Unsubscribe(notify) }()
+
+	for _, m := range []string{"panic", "ping"} {
+		if err = cli.Publish(context.Background(), subjectErrors+".panic.notify", &Request{Message: m}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	select {
+	case recovered := <-panics:
+		if recovered != "boom" {
+			t.Errorf("hook recovered = %v, want boom", recovered)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the hook is not called for the notify handler")
+	}
+
+	select {
+	case m := <-delivered:
+		if m != "ping" {
+			t.Errorf("delivered = %q, want ping", m)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the notify subscription stopped after the panic")
+	}
+}
+
+func TestErrors_DeadLetter(t *testing.T) {
+	var (
+		cli     = client.New(nil, "127.0.0.1:1222", "test", 100)
+		calls   = make(chan string, 10)
+		failed  = make(chan error, 1)
+		letters = make(chan *client.DeadLetter, 1)
+	)
+	defer cli.Close()
+
+	dl, err := cli.Subscribe(subjectErrors+".dlq", func(ctx context.Context, req *client.DeadLetter) error {
+		letters <- req
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = cli.Unsubscribe(dl) }()
+
+	sub, err := cli.Subscribe(subjectErrors+".notify", func(ctx context.Context, req *Request) error {
+		calls <- req.Message
+		return fmt.Errorf("store: %w", ErrItemNotFound)
+	}, client.SubErrorPolicy(client.ErrorPolicy{
+		Retries:    2,
+		Backoff:    10 * time.Millisecond,
+		MaxBackoff: 15 * time.Millisecond,
+		OnError: func(ctx context.Context, subject string, request interface{}, err error) {
+			failed <- err
+		},
+		DeadLetter: subjectErrors + ".dlq",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = cli.Unsubscribe(sub) }()
+
+	// the dead letter outlives the deadline of the publisher
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err = cli.Publish(ctx, subjectErrors+".notify", &Request{Message: "lost"}); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case err = <-failed:
+		if !errors.Is(err, ErrItemNotFound) {
+			t.Errorf("OnError error = %v, want ErrItemNotFound", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("OnError is not called")
+	}
+
+	if n := len(calls); n != 3 {
+		t.Errorf("handler calls = %d, want 3", n)
+	}
+
+	select {
+	case letter := <-letters:
+		var envelope struct{ Request Request }
+		switch {
+		case letter.Subject != subjectErrors+".notify":
+			t.Errorf("subject = %q", letter.Subject)
+		case letter.Attempts != 3:
+			t.Errorf("attempts = %d, want 3", letter.Attempts)
+		case letter.Error.Type == nil || *letter.Error.Type != "tests.ItemNotFound":
+			t.Errorf("error = %#v", letter.Error)
+		case json.Unmarshal(letter.Envelope, &envelope) != nil || envelope.Request.Message != "lost":
+			t.Errorf("envelope = %s", letter.Envelope)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("dead letter is not received")
+	}
+}
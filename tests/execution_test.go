@@ -0,0 +1,154 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/LRichi/wcNATS/client"
+)
+
+const subjectExecution = "test.subject.execution"
+
+func TestExecution(t *testing.T) {
+	var cli = client.New(nil, "127.0.0.1:1222", "test", 100)
+	defer cli.Close()
+
+	var tests = []struct {
+		name string
+		opts []client.SubscribeOption
+		peak int32
+	}{
+		{name: "TEST_SERIAL", peak: 1},
+		{name: "TEST_POOL", opts: []client.SubscribeOption{client.SubWorkers(3)}, peak: 3},
+		{name: "TEST_UNBOUNDED", opts: []client.SubscribeOption{client.SubExecution(client.ExecUnbounded)}, peak: 6},
+	}
+
+	for i, tt := range tests {
+		var subject = fmt.Sprint(subjectExecution, ".", i)
+		t.Run(tt.name, func(t *testing.T) {
+			var running, peak int32
+			sub, err := cli.Subscribe(subject, func(ctx context.Context, req *Request) (*Response, error) {
+				var n = atomic.AddInt32(&running, 1)
+				defer atomic.AddInt32(&running, -1)
+				for {
+					var p = atomic.LoadInt32(&peak)
+					if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+						break
+					}
+				}
+				time.Sleep(50 * time.Millisecond)
+				return &Response{}, nil
+			}, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() { _ = cli.Unsubscribe(sub) }()
+
+			var futures = make([]*client.Future, 6)
+			for i := range futures {
+				futures[i] = cli.RequestAsync(context.Background(), subject, &Request{}, &Response{})
+			}
+			for _, f := range futures {
+				if err := f.Wait(); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if p := atomic.LoadInt32(&peak); p != tt.peak {
+				t.Errorf("peak concurrency = %d, want %d", p, tt.peak)
+			}
+		})
+	}
+
+	t.Run("TEST_POOL_GOROUTINES", func(t *testing.T) {
+		var (
+			subject = subjectExecution + ".bounded"
+			release = make(chan struct{})
+		)
+		sub, err := cli.Subscribe(subject, func(ctx context.Context, req *Request) (*Response, error) {
+			<-release
+			return &Response{}, nil
+		}, client.SubWorkers(2))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = cli.Unsubscribe(sub) }()
+
+		// waiting messages stay in the pending buffer, they do not start goroutines
+		var (
+			base    = runtime.NumGoroutine()
+			futures = make([]*client.Future, 200)
+		)
+		for i := range futures {
+			futures[i] = cli.RequestAsync(context.Background(), subject, &Request{}, &Response{})
+		}
+		time.Sleep(100 * time.Millisecond)
+
+		if n := runtime.NumGoroutine() - base; n > 10 {
+			t.Errorf("goroutines of waiting messages = %d, want at most 10", n)
+		}
+
+		close(release)
+		for _, f := range futures {
+			if err := f.Wait(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	})
+
+	t.Run("TEST_MAX_IN_FLIGHT", func(t *testing.T) {
+		var (
+			subject = subjectExecution + ".limited"
+			started = make(chan struct{}, 4)
+			release = make(chan struct{})
+		)
+		sub, err := cli.Subscribe(subject, func(ctx context.Context, req *Request) (*Response, error) {
+			started <- struct{}{}
+			<-release
+			return &Response{}, nil
+		}, client.SubWorkers(1), client.SubMaxInFlight(2))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = cli.Unsubscribe(sub) }()
+
+		// the first call is running, the second one waits for the worker
+		var first = cli.RequestAsync(context.Background(), subject, &Request{}, &Response{})
+		<-started
+		var second = cli.RequestAsync(context.Background(), subject, &Request{}, &Response{})
+		time.Sleep(50 * time.Millisecond)
+
+		var (
+			wg       sync.WaitGroup
+			rejected int32
+		)
+		for i := 0; i < 3; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := cli.Request(context.Background(), subject, &Request{}, &Response{})
+				if client.CodeOf(err) == client.CodeResourceExhausted {
+					atomic.AddInt32(&rejected, 1)
+				} else {
+					t.Errorf("error = %v, want ResourceExhausted", err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		close(release)
+		for _, f := range []*client.Future{first, second} {
+			if err := f.Wait(); err != nil {
+				t.Error(err)
+			}
+		}
+		if rejected != 3 {
+			t.Errorf("rejected = %d, want 3", rejected)
+		}
+	})
+}
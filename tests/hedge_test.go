@@ -0,0 +1,126 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+
+	"github.com/LRichi/wcNATS/client"
+	"github.com/LRichi/wcNATS/prometheus"
+)
+
+const subjectHedge = "test.subject.hedge"
+
+func TestHedge(t *testing.T) {
+	var reg = prom.NewRegistry()
+	metrics, err := prometheus.New(reg, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		cli = client.New(nil, "127.0.0.1:1222", "test", 100,
+			client.WithMetrics(metrics),
+			client.WithHedging(subjectHedge+".p.*", client.HedgePolicy{Copies: 1, Delay: time.Second, Percentile: 0.5}),
+			client.WithHedging(subjectHedge+".>", client.HedgePolicy{Copies: 2, Delay: 20 * time.Millisecond}),
+		)
+		canceled = make(chan error, 1)
+		ids      = make(chan string, 3)
+	)
+	defer cli.Close()
+
+	// the original call is stuck, the hedged copy replies
+	sub, err := cli.QueueSubscribe(subjectHedge+".stuck", "hedge", func(ctx context.Context, req *Request) (*Response, error) {
+		var s = client.SessionFrom(ctx)
+		ids <- s.RequestID
+		if s.Hedge == 0 {
+			<-ctx.Done()
+			canceled <- ctx.Err()
+			return nil, ctx.Err()
+		}
+		return &Response{Message: fmt.Sprint("hedge ", s.Hedge)}, nil
+	}, client.SubExecution(client.ExecUnbounded))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = cli.Unsubscribe(sub) }()
+
+	t.Run("TEST_HEDGE_WINS", func(t *testing.T) {
+		var resp Response
+		if err := cli.Request(context.Background(), subjectHedge+".stuck", &Request{}, &resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Message != "hedge 1" {
+			t.Errorf("response = %q, want hedge 1", resp.Message)
+		}
+
+		select {
+		case err := <-canceled:
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("original call error = %v, want canceled", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("the original call is not canceled")
+		}
+
+		if first, second := <-ids, <-ids; first == "" || first != second {
+			t.Errorf("request IDs of copies = %q, %q, want the same", first, second)
+		}
+
+		labels := map[string]string{"subject": subjectHedge + ".stuck", "result": "hedge"}
+		if n := countOf(t, reg, "test_wcnats_request_hedges_total", labels); n != 1 {
+			t.Errorf("hedge wins = %d, want 1", n)
+		}
+	})
+
+	t.Run("TEST_FAST_REPLY", func(t *testing.T) {
+		fast, err := cli.Subscribe(subjectHedge+".fast", func(ctx context.Context, req *Request) (*Response, error) {
+			if client.SessionFrom(ctx).Hedge != 0 {
+				t.Error("the copy of the fast call is sent")
+			}
+			return &Response{}, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = cli.Unsubscribe(fast) }()
+
+		for i := 0; i < 5; i++ {
+			if err := cli.Request(context.Background(), subjectHedge+".fast", &Request{}, &Response{}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	})
+	// subjects matching the pattern share latencies, the percentile replaces the delay after enough calls
+	t.Run("TEST_PERCENTILE", func(t *testing.T) {
+		p, err := cli.Subscribe(subjectHedge+".p.*", func(ctx context.Context, req *Request) (*Response, error) {
+			if req.Message == "stuck" && client.SessionFrom(ctx).Hedge == 0 {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}
+			return &Response{}, nil
+		}, client.SubExecution(client.ExecUnbounded))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = cli.Unsubscribe(p) }()
+
+		for i := 0; i < 10; i++ {
+			if err := cli.Request(context.Background(), fmt.Sprint(subjectHedge, ".p.", i), &Request{}, &Response{}); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		var start = time.Now()
+		if err := cli.Request(context.Background(), subjectHedge+".p.stuck", &Request{Message: "stuck"}, &Response{}); err != nil {
+			t.Fatal(err)
+		}
+		if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+			t.Errorf("elapsed = %s, the copy is sent after the delay instead of the percentile", elapsed)
+		}
+	})
+}
@@ -0,0 +1,127 @@
+package tests
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/LRichi/wcNATS/client"
+)
+
+const subjectInterceptor = "test.subject.interceptor"
+
+func TestInterceptors(t *testing.T) {
+	var (
+		mux   sync.Mutex
+		order []string
+		trace = func(name string) {
+			mux.Lock()
+			order = append(order, name)
+			mux.Unlock()
+		}
+		notified = make(chan string, 1)
+	)
+
+	// the client adds the token, the server rejects calls without it
+	var (
+		auth = func(ctx context.Context, info client.CallInfo, req, resp interface{}, next client.Invoker) error {
+			trace("client " + string(info.Kind))
+			return next(client.WithMetadata(ctx, "token", "secret"), req, resp)
+		}
+		check = func(ctx context.Context, info client.CallInfo, req interface{}, next client.Handler) (interface{}, error) {
+			trace("server " + string(info.Kind))
+			if info.Metadata["token"] != "secret" {
+				return nil, client.Errorf(client.CodeUnauthenticated, "no token")
+			}
+			return next(ctx, req)
+		}
+		cli = client.New(nil, "127.0.0.1:1222", "test", 100,
+			client.WithInterceptors(auth),
+			client.WithServerInterceptors(check),
+		)
+		bare = client.New(nil, "127.0.0.1:1222", "test", 100)
+	)
+	defer cli.Close()
+	defer bare.Close()
+
+	// the interceptor of the subscription sees the decoded request and the result
+	var upper = func(ctx context.Context, info client.CallInfo, req interface{}, next client.Handler) (interface{}, error) {
+		trace("subscription")
+		if req.(*Request).Message == "short" {
+			return &Response{Message: "short-circuited"}, nil
+		}
+		resp, err := next(ctx, req)
+		if err == nil {
+			resp.(*Response).Message = strings.ToUpper(resp.(*Response).Message)
+		}
+		return resp, err
+	}
+
+	sub, err := cli.Subscribe(subjectInterceptor, func(ctx context.Context, req *Request) (*Response, error) {
+		trace("handler")
+		return &Response{Message: "echo " + req.Message}, nil
+	}, client.SubInterceptors(upper))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = cli.Unsubscribe(sub) }()
+
+	notify, err := cli.Subscribe(subjectInterceptor+".notify", func(ctx context.Context, req *Request) error {
+		notified <- req.Message
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = cli.Unsubscribe(notify) }()
+
+	t.Run("TEST_CHAIN", func(t *testing.T) {
+		order = nil
+
+		var resp Response
+		if err := cli.Request(context.Background(), subjectInterceptor, &Request{Message: "hi"}, &resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Message != "ECHO HI" {
+			t.Errorf("response = %q", resp.Message)
+		}
+
+		var want = []string{"client call", "server call", "subscription", "handler"}
+		if !reflect.DeepEqual(order, want) {
+			t.Errorf("order = %v, want %v", order, want)
+		}
+	})
+
+	t.Run("TEST_SHORT_CIRCUIT", func(t *testing.T) {
+		var resp Response
+		if err := cli.Request(context.Background(), subjectInterceptor, &Request{Message: "short"}, &resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Message != "short-circuited" {
+			t.Errorf("response = %q", resp.Message)
+		}
+
+		err := bare.Request(context.Background(), subjectInterceptor, &Request{Message: "hi"}, &resp)
+		if client.CodeOf(err) != client.CodeUnauthenticated {
+			t.Errorf("error = %v, want Unauthenticated", err)
+		}
+	})
+
+	t.Run("TEST_NOTIFY", func(t *testing.T) {
+		if err := cli.Publish(context.Background(), subjectInterceptor+".notify", &Request{Message: "note"}); err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case msg := <-notified:
+			if msg != "note" {
+				t.Errorf("notification = %q", msg)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("the notification is not received")
+		}
+	})
+}
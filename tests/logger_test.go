@@ -0,0 +1,154 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/LRichi/wcNATS/client"
+)
+
+const subjectLogger = "test.subject.logger"
+
+// recorder - counts entries of the client by subject
+type recorder struct {
+	mux     sync.Mutex
+	entries map[string]int
+}
+
+func (r *recorder) record(keysAndValues ...interface{}) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		if keysAndValues[i] == "subject" {
+			r.entries[keysAndValues[i+1].(string)]++
+		}
+	}
+}
+
+func (r *recorder) Debugw(_ string, keysAndValues ...interface{}) { r.record(keysAndValues...) }
+func (r *recorder) Infow(_ string, keysAndValues ...interface{})  { r.record(keysAndValues...) }
+func (r *recorder) Warnw(_ string, keysAndValues ...interface{})  { r.record(keysAndValues...) }
+func (r *recorder) Errorw(_ string, keysAndValues ...interface{}) { r.record(keysAndValues...) }
+
+func TestLogger_Subjects(t *testing.T) {
+	var (
+		rec = &recorder{entries: make(map[string]int)}
+		cli = client.New(rec, "127.0.0.1:1222", "test", 100,
+			client.WithLogLevel(subjectLogger+".quiet.>", client.InfoLevel),
+			client.WithLogSampling(subjectLogger+".sampled", 3),
+		)
+	)
+	defer cli.Close()
+
+	tests := []struct {
+		name    string
+		subject string
+		want    int
+	}{
+		{
+			name:    "TEST_DEFAULT",
+			subject: subjectLogger + ".default",
+			want:    6,
+		},
+		{
+			name:    "TEST_LEVEL",
+			subject: subjectLogger + ".quiet.any",
+			want:    0,
+		},
+		{
+			name:    "TEST_SAMPLING",
+			subject: subjectLogger + ".sampled",
+			want:    2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 6; i++ {
+				if err := cli.Publish(context.Background(), tt.subject, &Request{Message: "log"}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			rec.mux.Lock()
+			defer rec.mux.Unlock()
+			if got := rec.entries[tt.subject]; got != tt.want {
+				t.Errorf("entries = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+type Credentials struct {
+	Login    string
+	Password string `wcnats:"redact"`
+	Token    string `json:"token" wcnats:"redact"`
+}
+
+// capture - keeps logged values of the key
+type capture struct {
+	mux    sync.Mutex
+	key    string
+	values []string
+}
+
+func (c *capture) record(keysAndValues ...interface{}) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		if keysAndValues[i] == c.key {
+			c.values = append(c.values, fmt.Sprint(keysAndValues[i+1]))
+		}
+	}
+}
+
+func (c *capture) Debugw(_ string, keysAndValues ...interface{}) { c.record(keysAndValues...) }
+func (c *capture) Infow(_ string, keysAndValues ...interface{})  { c.record(keysAndValues...) }
+func (c *capture) Warnw(_ string, keysAndValues ...interface{})  { c.record(keysAndValues...) }
+func (c *capture) Errorw(_ string, keysAndValues ...interface{}) { c.record(keysAndValues...) }
+
+func TestLogger_Redaction(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []client.Option
+		want string
+	}{
+		{
+			name: "TEST_TAGS",
+			want: `{"Login":"admin","Password":"[REDACTED]","token":"[REDACTED]"}`,
+		},
+		{
+			name: "TEST_HOOK",
+			opts: []client.Option{client.WithRedactHook(func(subject string, v interface{}) interface{} {
+				return map[string]string{"subject": subject}
+			})},
+			want: `{"subject":"test.subject.logger.redact"}`,
+		},
+		{
+			name: "TEST_TRUNCATION",
+			opts: []client.Option{client.WithMaxLoggedSize(10)},
+			want: `"{\"Login\":\"...(truncated 52 bytes)"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				c   = &capture{key: "value"}
+				cli = client.New(c, "127.0.0.1:1222", "test", 100, tt.opts...)
+			)
+			defer cli.Close()
+
+			var creds = Credentials{Login: "admin", Password: "secret", Token: "xyz"}
+			if err := cli.Publish(context.Background(), subjectLogger+".redact", &creds); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(c.values) != 1 || c.values[0] != tt.want {
+				t.Errorf("logged = %v, want %s", c.values, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/LRichi/wcNATS/client"
+)
+
+const subjectMany = "test.subject.many"
+
+func TestRequestMany(t *testing.T) {
+	var cli = client.New(nil, "127.0.0.1:1222", "test", 100)
+	defer cli.Close()
+
+	// two warehouses have the item, the third one fails
+	for i := 0; i < 3; i++ {
+		var i = i
+		sub, err := cli.Subscribe(subjectMany, func(ctx context.Context, req *Request) (*Response, error) {
+			if i == 2 {
+				return nil, ErrItemNotFound
+			}
+			return &Response{Message: fmt.Sprint("warehouse ", i)}, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = cli.Unsubscribe(sub) }()
+	}
+
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		opts    []client.ManyOption
+	}{
+		{name: "TEST_COUNT", opts: []client.ManyOption{client.ManyCount(3)}},
+		{name: "TEST_QUIET", opts: []client.ManyOption{client.ManyQuiet(200 * time.Millisecond)}},
+		{name: "TEST_DEADLINE", timeout: 300 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ctx = context.Background()
+			if tt.timeout != 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+
+			var responses []*Response
+			errs, err := cli.RequestMany(ctx, subjectMany, &Request{Message: "stock"}, &responses, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(responses) != 3 || len(errs) != 3 {
+				t.Fatalf("responses = %d, errors = %d, want 3", len(responses), len(errs))
+			}
+
+			var found, missing int
+			for i, e := range errs {
+				switch {
+				case errors.Is(e, ErrItemNotFound):
+					missing++
+				case e == nil && responses[i].Message != "":
+					found++
+				default:
+					t.Errorf("reply %d: %q, %v", i, responses[i].Message, e)
+				}
+			}
+			if found != 2 || missing != 1 {
+				t.Errorf("found = %d, missing = %d, want 2 and 1", found, missing)
+			}
+		})
+	}
+
+	t.Run("TEST_ITERATOR", func(t *testing.T) {
+		replies, err := cli.RequestManyIter(context.Background(), subjectMany, &Request{Message: "stock"},
+			client.ManyCount(2))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var n int
+		for replies.Next() {
+			var resp Response
+			if err = replies.Decode(&resp); err != nil && !errors.Is(err, ErrItemNotFound) {
+				t.Error(err)
+			}
+			n++
+		}
+		if err = replies.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if n != 2 {
+			t.Errorf("replies = %d, want 2", n)
+		}
+	})
+
+	t.Run("TEST_NO_RESPONDERS", func(t *testing.T) {
+		var responses []*Response
+		_, err := cli.RequestMany(context.Background(), subjectMany+".nobody", &Request{Message: "stock"}, &responses,
+			client.ManyQuiet(time.Second))
+		if !errors.As(err, &client.NoResponders{}) {
+			t.Errorf("error %#v is not NoResponders", err)
+		}
+	})
+}
@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/LRichi/wcNATS/client"
+)
+
+const subjectMetadata = "test.subject.metadata"
+
+func TestMetadata_Request(t *testing.T) {
+	lc := zap.NewDevelopmentConfig()
+	lc.DisableStacktrace = true
+	lc.DisableCaller = true
+
+	log, err := lc.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		policy = client.MetadataPolicy{Allow: []string{"tenant", "locale"}, MaxKeys: 2, MaxSize: 64}
+		cli    = client.New(log.Sugar().Named("NATS").Named("CLIENT"), "127.0.0.1:1222", "test", 100,
+			client.WithMetadataPolicy(policy),
+		)
+		got client.Metadata
+	)
+	defer cli.Close()
+
+	sub, err := cli.Subscribe(subjectMetadata, func(ctx context.Context, req *Request) (*Response, error) {
+		got = client.MetadataFrom(ctx)
+		return &Response{Message: got["tenant"]}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = cli.Unsubscribe(sub) }()
+
+	// the first hop calls the handler above with its incoming context
+	hop, err := cli.Subscribe(subjectMetadata+".hop", func(ctx context.Context, req *Request) (*Response, error) {
+		var resp Response
+		err := cli.Request(ctx, subjectMetadata, req, &resp)
+		return &resp, err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = cli.Unsubscribe(hop) }()
+
+	tests := []struct {
+		name    string
+		md      map[string]string
+		want    client.Metadata
+		wantErr bool
+	}{
+		{
+			name: "TEST_ALLOWED_KEYS",
+			md:   map[string]string{"tenant": "acme", "locale": "en"},
+			want: client.Metadata{"tenant": "acme", "locale": "en"},
+		},
+		{
+			name: "TEST_NOT_ALLOWED_KEYS",
+			md:   map[string]string{"tenant": "acme", "password": "secret"},
+			want: client.Metadata{"tenant": "acme"},
+		},
+		{
+			name:    "TEST_SIZE_LIMIT",
+			md:      map[string]string{"tenant": string(make([]byte, 128))},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ctx = context.Background()
+			for k, v := range tt.md {
+				ctx = client.WithMetadata(ctx, k, v)
+			}
+
+			got = nil
+			var resp Response
+			if err = cli.Request(ctx, subjectMetadata, &Request{Message: "md"}, &resp); (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("metadata = %v, want %v", got, tt.want)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("metadata[%s] = %q, want %q", k, got[k], v)
+				}
+			}
+		})
+	}
+
+	t.Run("TEST_FORWARDING", func(t *testing.T) {
+		var ctx = client.WithMetadata(context.Background(), "tenant", "acme")
+
+		got = nil
+		var resp Response
+		if err = cli.Request(ctx, subjectMetadata+".hop", &Request{Message: "md"}, &resp); err != nil {
+			t.Fatal(err)
+		}
+
+		if got["tenant"] != "acme" || resp.Message != "acme" {
+			t.Errorf("metadata of the second hop = %v, response %q", got, resp.Message)
+		}
+	})
+}
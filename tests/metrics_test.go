@@ -0,0 +1,136 @@
+package tests
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"github.com/LRichi/wcNATS/client"
+	"github.com/LRichi/wcNATS/prometheus"
+)
+
+const subjectMetrics = "test.subject.metrics"
+
+// countOf - returns the number of observations of the metric with labels
+func countOf(t *testing.T, reg *prom.Registry, name string, labels map[string]string) uint64 {
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, f := range families {
+		if f.GetName() != name {
+			continue
+		}
+	metrics:
+		for _, m := range f.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if v, ok := labels[l.GetName()]; ok && v != l.GetValue() {
+					continue metrics
+				}
+			}
+			switch {
+			case m.GetHistogram() != nil:
+				return m.GetHistogram().GetSampleCount()
+			case m.GetCounter() != nil:
+				return uint64(m.GetCounter().GetValue())
+			}
+		}
+	}
+
+	return 0
+}
+
+func TestMetrics_Prometheus(t *testing.T) {
+	lc := zap.NewDevelopmentConfig()
+	lc.DisableStacktrace = true
+	lc.DisableCaller = true
+
+	log, err := lc.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var reg = prom.NewRegistry()
+	metrics, err := prometheus.New(reg, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		cli = client.New(log.Sugar().Named("NATS").Named("CLIENT"), "127.0.0.1:1222", "test", 100,
+			client.WithMetrics(metrics),
+		)
+		r   = &right{wg: &sync.WaitGroup{}, log: log.Sugar()}
+		ctx = client.WithCaller(context.Background(), "goTest", "metricsTest")
+	)
+	defer cli.Close()
+
+	call, err := cli.Subscribe(subjectMetrics+".call", r.receiveCall)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = cli.Unsubscribe(call) }()
+
+	notify, err := cli.Subscribe(subjectMetrics+".notify", r.receiveNotify)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = cli.Unsubscribe(notify) }()
+
+	_ = cli.Request(ctx, subjectMetrics+".call", &Request{Message: "ok"}, &Response{})
+	_ = cli.Request(ctx, subjectMetrics+".call", &Request{}, &Response{})
+	_ = cli.Request(ctx, subjectMetrics+".none", &Request{Message: "ok"}, &Response{})
+
+	r.wg.Add(1)
+	_ = cli.Publish(ctx, subjectMetrics+".notify", &Request{Message: "ok"})
+	r.wg.Wait()
+
+	tests := []struct {
+		name   string
+		metric string
+		labels map[string]string
+		want   uint64
+	}{
+		{
+			name:   "TEST_REQUEST_OK",
+			metric: "test_wcnats_request_duration_seconds",
+			labels: map[string]string{"subject": subjectMetrics + ".call", "outcome": "ok"},
+			want:   1,
+		},
+		{
+			name:   "TEST_REQUEST_HANDLER_ERROR",
+			metric: "test_wcnats_request_duration_seconds",
+			labels: map[string]string{"subject": subjectMetrics + ".call", "outcome": "error"},
+			want:   1,
+		},
+		{
+			name:   "TEST_REQUEST_NO_RESPONDERS",
+			metric: "test_wcnats_request_duration_seconds",
+			labels: map[string]string{"subject": subjectMetrics + ".none", "outcome": "no_responders"},
+			want:   1,
+		},
+		{
+			name:   "TEST_PUBLISH",
+			metric: "test_wcnats_publish_total",
+			labels: map[string]string{"subject": subjectMetrics + ".notify", "outcome": "ok"},
+			want:   1,
+		},
+		{
+			name:   "TEST_HANDLER_CALL",
+			metric: "test_wcnats_handler_duration_seconds",
+			labels: map[string]string{"subject": subjectMetrics + ".call", "kind": "call", "outcome": "error"},
+			want:   1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := countOf(t, reg, tt.metric, tt.labels); got != tt.want {
+				t.Errorf("count = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/LRichi/wcNATS/client"
+)
+
+const subjectQueue = "test.subject.queue"
+
+func TestQueue_OneOfN(t *testing.T) {
+	const (
+		members  = 3
+		messages = 30
+	)
+
+	var cli = client.New(nil, "127.0.0.1:1222", "test", 100)
+	defer cli.Close()
+
+	var (
+		calls    [members]int32
+		notifies [members]int32
+		wg       sync.WaitGroup
+	)
+	for i := 0; i < members; i++ {
+		var i = i
+
+		call, err := cli.QueueSubscribe(subjectQueue+".call", "workers", func(ctx context.Context, req *Request) (*Response, error) {
+			atomic.AddInt32(&calls[i], 1)
+			return &Response{Message: fmt.Sprint(i)}, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = cli.Unsubscribe(call) }()
+
+		notify, err := cli.Subscribe(subjectQueue+".notify", func(ctx context.Context, req *Request) error {
+			atomic.AddInt32(&notifies[i], 1)
+			wg.Done()
+			return nil
+		}, client.SubQueue("workers"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = cli.Unsubscribe(notify) }()
+	}
+
+	wg.Add(messages)
+	for n := 0; n < messages; n++ {
+		if err := cli.Request(context.Background(), subjectQueue+".call", &Request{Message: "work"}, &Response{}); err != nil {
+			t.Fatal(err)
+		}
+		if err := cli.Publish(context.Background(), subjectQueue+".notify", &Request{Message: "work"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+
+	// the late duplicate delivery would be counted too
+	time.Sleep(50 * time.Millisecond)
+
+	for name, counts := range map[string]*[members]int32{"calls": &calls, "notifies": &notifies} {
+		var total, used int32
+		for i := range counts {
+			if n := atomic.LoadInt32(&counts[i]); n != 0 {
+				total += n
+				used++
+			}
+		}
+		if total != messages {
+			t.Errorf("%s delivered %d times, want %d", name, total, messages)
+		}
+		if used < 2 {
+			t.Errorf("%s are not balanced: %v", name, *counts)
+		}
+	}
+}
+
+func TestQueue_BadName(t *testing.T) {
+	var cli = client.New(nil, "127.0.0.1:1222", "test", 100)
+	defer cli.Close()
+
+	for _, queue := range []string{"", "bad queue"} {
+		_, err := cli.QueueSubscribe(subjectQueue+".bad", queue, func(ctx context.Context, req *Request) error {
+			return nil
+		})
+		if !errors.As(err, &client.BadQueueName{}) {
+			t.Errorf("queue %q: error %#v is not BadQueueName", queue, err)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/LRichi/wcNATS/client"
+)
+
+const subjectRateLimit = "test.subject.ratelimit"
+
+func TestRateLimit(t *testing.T) {
+	var cli = client.New(nil, "127.0.0.1:1222", "test", 100,
+		client.WithRateLimit(subjectRateLimit+".client", client.RateLimit{Rate: 10, Burst: 2}),
+		client.WithServerRateLimit(subjectRateLimit+".server", client.RateLimit{Rate: 1, Burst: 1}),
+		client.WithRateLimit(subjectRateLimit+".ids.*", client.RateLimit{Rate: 1, Burst: 2}),
+		client.WithServerRateLimit(subjectRateLimit+".shared.*", client.RateLimit{Rate: 1, Burst: 2}),
+	)
+	defer cli.Close()
+
+	var echo = func(ctx context.Context, req *Request) (*Response, error) {
+		return &Response{Message: req.Message}, nil
+	}
+	for _, subject := range []string{subjectRateLimit + ".client", subjectRateLimit + ".server",
+		subjectRateLimit + ".ids.*", subjectRateLimit + ".shared.*"} {
+		sub, err := cli.Subscribe(subject, echo)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = cli.Unsubscribe(sub) }()
+	}
+
+	var call = func(subject string) error {
+		return cli.Request(context.Background(), subject, &Request{}, &Response{})
+	}
+
+	t.Run("TEST_FAIL_FAST", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			if err := call(subjectRateLimit + ".client"); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		var rl *client.RateLimited
+		if err := call(subjectRateLimit + ".client"); !errors.As(err, &rl) || rl.RetryAfter <= 0 {
+			t.Fatalf("error = %v, want RateLimited", err)
+		}
+	})
+
+	t.Run("TEST_WAIT", func(t *testing.T) {
+		cli.SetRateLimit(subjectRateLimit+".client", client.RateLimit{Rate: 20, Burst: 1, Wait: true})
+
+		var start = time.Now()
+		for i := 0; i < 3; i++ {
+			if err := call(subjectRateLimit + ".client"); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+			t.Errorf("elapsed = %s, calls are not limited", elapsed)
+		}
+
+		// the token does not come before the deadline
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		var rl *client.RateLimited
+		if err := cli.Request(ctx, subjectRateLimit+".client", &Request{}, &Response{}); !errors.As(err, &rl) {
+			t.Errorf("error = %v, want RateLimited", err)
+		}
+	})
+
+	t.Run("TEST_REMOVE", func(t *testing.T) {
+		cli.SetRateLimit(subjectRateLimit+".client", client.RateLimit{})
+		for i := 0; i < 10; i++ {
+			if err := call(subjectRateLimit + ".client"); err != nil {
+				t.Fatal(err)
+			}
+		}
+	})
+
+	t.Run("TEST_SERVER", func(t *testing.T) {
+		if err := call(subjectRateLimit + ".server"); err != nil {
+			t.Fatal(err)
+		}
+
+		var (
+			err = call(subjectRateLimit + ".server")
+			rl  *client.RateLimited
+		)
+		if !errors.As(err, &rl) || rl.RetryAfter <= 0 || client.CodeOf(err) != client.CodeResourceExhausted {
+			t.Fatalf("error = %v, want RateLimited", err)
+		}
+
+		cli.SetServerRateLimit(subjectRateLimit+".server", client.RateLimit{Rate: 1000, Burst: 10})
+		time.Sleep(20 * time.Millisecond)
+		if err := call(subjectRateLimit + ".server"); err != nil {
+			t.Error(err)
+		}
+	})
+
+	// subjects matching the pattern share its bucket, varying the token of the subject does not bypass the limit
+	t.Run("TEST_SHARED", func(t *testing.T) {
+		for _, pattern := range []string{subjectRateLimit + ".ids.", subjectRateLimit + ".shared."} {
+			for i := 0; i < 2; i++ {
+				if err := call(fmt.Sprint(pattern, i)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var rl *client.RateLimited
+			if err := call(pattern + "2"); !errors.As(err, &rl) {
+				t.Errorf("error of %s = %v, want RateLimited", pattern, err)
+			}
+		}
+	})
+}
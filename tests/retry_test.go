@@ -0,0 +1,171 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+
+	"github.com/LRichi/wcNATS/client"
+	"github.com/LRichi/wcNATS/prometheus"
+)
+
+const subjectRetry = "test.subject.retry"
+
+// slowFirst - returns handler which is slower than the attempt timeout on the first call
+func slowFirst(calls *int32) func(ctx context.Context, req *Request) (*Response, error) {
+	return func(ctx context.Context, req *Request) (*Response, error) {
+		if atomic.AddInt32(calls, 1) == 1 {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return &Response{Message: req.Message}, nil
+	}
+}
+
+func TestRetry_NoResponders(t *testing.T) {
+	var reg = prom.NewRegistry()
+	metrics, err := prometheus.New(reg, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cli = client.New(nil, "127.0.0.1:1222", "test", 100,
+		client.WithMetrics(metrics),
+		client.WithRetryPolicy(subjectRetry+".>", client.RetryPolicy{
+			MaxAttempts: 20,
+			Backoff:     10 * time.Millisecond,
+			MaxBackoff:  50 * time.Millisecond,
+			Jitter:      0.5,
+		}),
+	)
+	defer cli.Close()
+
+	// the responder appears after the first attempts
+	var subscribed = make(chan client.Subscription, 1)
+	time.AfterFunc(100*time.Millisecond, func() {
+		sub, err := cli.Subscribe(subjectRetry+".late", func(ctx context.Context, req *Request) (*Response, error) {
+			return &Response{Message: req.Message}, nil
+		})
+		if err != nil {
+			t.Error(err)
+		}
+		subscribed <- sub
+	})
+	defer func() {
+		if sub := <-subscribed; sub != nil {
+			_ = cli.Unsubscribe(sub)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	var resp Response
+	if err = cli.Request(ctx, subjectRetry+".late", &Request{Message: "retried"}, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Message != "retried" {
+		t.Errorf("response = %q, want retried", resp.Message)
+	}
+
+	labels := map[string]string{"subject": subjectRetry + ".late", "outcome": string(client.OutcomeNoResponders)}
+	if n := countOf(t, reg, "test_wcnats_request_retries_total", labels); n == 0 {
+		t.Error("retries are not counted")
+	}
+}
+
+func TestRetry_AttemptTimeout(t *testing.T) {
+	var (
+		calls int32
+		cli   = client.New(nil, "127.0.0.1:1222", "test", 100,
+			client.WithRetryPolicy(">", client.RetryPolicy{MaxAttempts: 3, AttemptTimeout: 100 * time.Millisecond}),
+		)
+	)
+	defer cli.Close()
+
+	sub, err := cli.Subscribe(subjectRetry+".slow", slowFirst(&calls))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = cli.Unsubscribe(sub) }()
+
+	var resp Response
+	if err = cli.Request(context.Background(), subjectRetry+".slow", &Request{Message: "second"}, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Message != "second" {
+		t.Errorf("response = %q, want second", resp.Message)
+	}
+	if n := atomic.LoadInt32(&calls); n != 2 {
+		t.Errorf("handler calls = %d, want 2", n)
+	}
+}
+
+func TestRetry_NonIdempotent(t *testing.T) {
+	var (
+		calls int32
+		cli   = client.New(nil, "127.0.0.1:1222", "test", 100,
+			client.WithRetryPolicy(">", client.RetryPolicy{MaxAttempts: 3, AttemptTimeout: 100 * time.Millisecond}),
+		)
+	)
+	defer cli.Close()
+
+	sub, err := cli.Subscribe(subjectRetry+".once", slowFirst(&calls), client.SubNonIdempotent(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = cli.Unsubscribe(sub) }()
+
+	err = cli.Request(context.Background(), subjectRetry+".once", &Request{Message: "once"}, &Response{})
+	switch {
+	case !errors.Is(err, client.ErrDuplicateRequest):
+		t.Errorf("error %#v is not ErrDuplicateRequest", err)
+	case client.CodeOf(err) != client.CodeAborted:
+		t.Errorf("code = %s, want %s", client.CodeOf(err), client.CodeAborted)
+	}
+
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("handler calls = %d, want 1", n)
+	}
+}
+
+func TestRetry_NonIdempotentQueued(t *testing.T) {
+	var (
+		calls int32
+		cli   = client.New(nil, "127.0.0.1:1222", "test", 100,
+			client.WithRetryPolicy(">", client.RetryPolicy{MaxAttempts: 10, AttemptTimeout: 50 * time.Millisecond}),
+		)
+	)
+	defer cli.Close()
+
+	sub, err := cli.Subscribe(subjectRetry+".queued", func(ctx context.Context, req *Request) (*Response, error) {
+		if req.Message == "block" {
+			time.Sleep(150 * time.Millisecond)
+			return &Response{}, nil
+		}
+		atomic.AddInt32(&calls, 1)
+		return &Response{Message: req.Message}, nil
+	}, client.SubNonIdempotent(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = cli.Unsubscribe(sub) }()
+
+	// the first attempts expire in the queue behind the blocking call and must not be remembered
+	go func() {
+		_ = cli.Request(context.Background(), subjectRetry+".queued", &Request{Message: "block"}, &Response{})
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	var resp Response
+	if err = cli.Request(context.Background(), subjectRetry+".queued", &Request{Message: "queued"}, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("handler calls = %d, want 1", n)
+	}
+}
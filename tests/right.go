@@ -7,6 +7,8 @@ import (
 	"sync"
 
 	"go.uber.org/zap"
+
+	"github.com/LRichi/wcNATS/client"
 )
 
 const subjectRequest = "test.subject.request"
@@ -25,9 +27,12 @@ type right struct {
 }
 
 func (r *right) receiveCall(ctx context.Context, req *Request) (*Response, error) {
-	var caller = []string{"service", ctx.Value("service").(string), "session", ctx.Value("method").(string)}
+	var (
+		session = client.SessionFrom(ctx)
+		caller  = []string{"service", session.Service, "session", session.Method}
+	)
 	r.log.Infow("received call",
-		"session", ctx.Value("session"), "caller", strings.Join(caller, "."), "request", req)
+		"session", session.ID, "caller", strings.Join(caller, "."), "request", req)
 
 	if req.Message == "" {
 		return &Response{}, fmt.Errorf("no messge")
@@ -39,9 +44,12 @@ func (r *right) receiveCall(ctx context.Context, req *Request) (*Response, error
 func (r *right) receiveNotify(ctx context.Context, req *Request) error {
 	defer r.wg.Done()
 
-	var caller = []string{"service", ctx.Value("service").(string), "session", ctx.Value("method").(string)}
+	var (
+		session = client.SessionFrom(ctx)
+		caller  = []string{"service", session.Service, "session", session.Method}
+	)
 	r.log.Infow("received notify",
-		"session", ctx.Value("session"), "caller", strings.Join(caller, "."), "request", req)
+		"session", session.ID, "caller", strings.Join(caller, "."), "request", req)
 
 	// if use return error, search in NATS client debug
 	if req.Message == "" {
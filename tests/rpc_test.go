@@ -15,9 +15,8 @@ func requestTestWithoutError(ctx context.Context, log *zap.SugaredLogger, cli *c
 	var (
 		r = &right{log: log}
 	)
-	ctx = context.WithValue(ctx, "session", "111111")
-	ctx = context.WithValue(ctx, "service", "goTest")
-	ctx = context.WithValue(ctx, "method", "requestTest")
+	ctx = client.WithSession(ctx, "111111")
+	ctx = client.WithCaller(ctx, "goTest", "requestTest")
 
 	sub, err := cli.Subscribe(subjectRequest, r.receiveCall)
 	if err != nil {
@@ -45,9 +44,8 @@ func requestTestWithError(ctx context.Context, log *zap.SugaredLogger, cli *clie
 	var (
 		r = &right{log: log}
 	)
-	ctx = context.WithValue(ctx, "session", "2222222")
-	ctx = context.WithValue(ctx, "service", "goTest")
-	ctx = context.WithValue(ctx, "method", "requestTest")
+	ctx = client.WithSession(ctx, "2222222")
+	ctx = client.WithCaller(ctx, "goTest", "requestTest")
 
 	sub, err := cli.Subscribe(subjectRequest, r.receiveCall)
 	if err != nil {
@@ -78,9 +76,8 @@ func notifyTestWithoutError(ctx context.Context, log *zap.SugaredLogger, cli *cl
 			log: log,
 		}
 	)
-	ctx = context.WithValue(ctx, "session", "222222")
-	ctx = context.WithValue(ctx, "service", "goTest")
-	ctx = context.WithValue(ctx, "method", "notifyTest")
+	ctx = client.WithSession(ctx, "222222")
+	ctx = client.WithCaller(ctx, "goTest", "notifyTest")
 
 	sub, err := cli.Subscribe(subjectRequest, r.receiveNotify)
 	if err != nil {
@@ -112,6 +109,7 @@ func notifyTestWithError(ctx context.Context, log *zap.SugaredLogger, cli *clien
 			log: log,
 		}
 	)
+	// legacy string keys are still read by the client
 	ctx = context.WithValue(ctx, "session", "222222")
 	ctx = context.WithValue(ctx, "service", "goTest")
 	ctx = context.WithValue(ctx, "method", "notifyTest")
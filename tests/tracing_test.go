@@ -0,0 +1,126 @@
+package tests
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/LRichi/wcNATS/client"
+)
+
+const subjectTracing = "test.subject.tracing"
+
+func TestTracing_Request(t *testing.T) {
+	lc := zap.NewDevelopmentConfig()
+	lc.DisableStacktrace = true
+	lc.DisableCaller = true
+
+	log, err := lc.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		exporter = tracetest.NewInMemoryExporter()
+		provider = sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+		cli      = client.New(log.Sugar().Named("NATS").Named("CLIENT"), "127.0.0.1:1222", "test", 100,
+			client.WithTracerProvider(provider),
+		)
+		r = &right{wg: &sync.WaitGroup{}, log: log.Sugar()}
+	)
+	defer cli.Close()
+
+	call, err := cli.Subscribe(subjectTracing+".call", r.receiveCall)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = cli.Unsubscribe(call) }()
+
+	notify, err := cli.Subscribe(subjectTracing+".notify", r.receiveNotify)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = cli.Unsubscribe(notify) }()
+
+	tests := []struct {
+		name       string
+		handle     func(ctx context.Context) error
+		sendKind   trace.SpanKind
+		handleKind trace.SpanKind
+		wantErr    bool
+	}{
+		{
+			name: "TEST_REQUEST",
+			handle: func(ctx context.Context) error {
+				return cli.Request(ctx, subjectTracing+".call", &Request{Message: "traced"}, &Response{})
+			},
+			sendKind:   trace.SpanKindClient,
+			handleKind: trace.SpanKindServer,
+		},
+		{
+			name: "TEST_REQUEST_WITH_ERROR",
+			handle: func(ctx context.Context) error {
+				return cli.Request(ctx, subjectTracing+".call", &Request{}, &Response{})
+			},
+			sendKind:   trace.SpanKindClient,
+			handleKind: trace.SpanKindServer,
+			wantErr:    true,
+		},
+		{
+			name: "TEST_PUBLISH",
+			handle: func(ctx context.Context) error {
+				r.wg.Add(1)
+				defer r.wg.Wait()
+				return cli.Publish(ctx, subjectTracing+".notify", &Request{Message: "traced"})
+			},
+			sendKind:   trace.SpanKindProducer,
+			handleKind: trace.SpanKindConsumer,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter.Reset()
+
+			var ctx = client.WithCaller(context.Background(), "goTest", "tracingTest")
+			if err = tt.handle(ctx); (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			// the handler span ends after the handler returns
+			time.Sleep(50 * time.Millisecond)
+
+			var send, handle *tracetest.SpanStub
+			for i, s := range exporter.GetSpans() {
+				switch s.SpanKind {
+				case tt.sendKind:
+					send = &exporter.GetSpans()[i]
+				case tt.handleKind:
+					handle = &exporter.GetSpans()[i]
+				}
+			}
+			if send == nil || handle == nil {
+				t.Fatalf("spans are not recorded: %v", exporter.GetSpans())
+			}
+
+			if handle.SpanContext.TraceID() != send.SpanContext.TraceID() {
+				t.Errorf("trace id = %s, want %s", handle.SpanContext.TraceID(), send.SpanContext.TraceID())
+			}
+			if handle.Parent.SpanID() != send.SpanContext.SpanID() {
+				t.Errorf("parent span id = %s, want %s", handle.Parent.SpanID(), send.SpanContext.SpanID())
+			}
+
+			if tt.wantErr {
+				if send.Status.Code != codes.Error || handle.Status.Code != codes.Error {
+					t.Errorf("status = %v/%v, want error", send.Status.Code, handle.Status.Code)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/LRichi/wcNATS/client"
+)
+
+const subjectValidate = "test.subject.validate"
+
+type Item struct {
+	Name string `validate:"required"`
+}
+
+type Order struct {
+	ID     string `validate:"required,regex=^[0-9a-f]{8}$"`
+	Status string `validate:"enum=new|paid"`
+	Amount int    `validate:"min=1,max=1000"`
+	Items  []Item `json:"items" validate:"required,max=3"`
+}
+
+// uncheckedOrder - the same message without rules, the server validates it
+type uncheckedOrder struct {
+	ID     string
+	Status string
+	Amount int
+	Items  []struct{ Name string } `json:"items"`
+}
+
+func TestValidate_Request(t *testing.T) {
+	var (
+		calls int32
+		cli   = client.New(nil, "127.0.0.1:1222", "test", 100)
+	)
+	defer cli.Close()
+
+	sub, err := cli.Subscribe(subjectValidate, func(ctx context.Context, req *Order) (*Response, error) {
+		atomic.AddInt32(&calls, 1)
+		return &Response{Message: req.Status}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = cli.Unsubscribe(sub) }()
+
+	tests := []struct {
+		name   string
+		req    interface{}
+		fields []string
+		calls  int32
+	}{
+		{
+			name:  "TEST_VALID",
+			req:   &Order{ID: "0a1b2c3d", Status: "paid", Amount: 10, Items: []Item{{Name: "book"}}},
+			calls: 1,
+		},
+		{
+			name:   "TEST_CLIENT",
+			req:    &Order{ID: "x", Status: "lost", Amount: 0},
+			fields: []string{"ID", "Status", "Amount", "items"},
+		},
+		{
+			name: "TEST_SERVER",
+			req: &uncheckedOrder{ID: "0a1b2c3d", Status: "new", Amount: 5000,
+				Items: []struct{ Name string }{{Name: "book"}, {}}},
+			fields: []string{"Amount", "items[1].Name"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			atomic.StoreInt32(&calls, 0)
+
+			err := cli.Request(context.Background(), subjectValidate, tt.req, &Response{})
+			if n := atomic.LoadInt32(&calls); n != tt.calls {
+				t.Errorf("handler calls = %d, want %d", n, tt.calls)
+			}
+
+			if tt.fields == nil {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			var ia *client.InvalidArgument
+			if !errors.As(err, &ia) {
+				t.Fatalf("error %#v is not InvalidArgument", err)
+			}
+			if client.CodeOf(err) != client.CodeInvalidArgument {
+				t.Errorf("code = %s, want %s", client.CodeOf(err), client.CodeInvalidArgument)
+			}
+
+			var got = make(map[string]bool)
+			for _, v := range ia.Violations {
+				got[v.Field] = true
+			}
+			if len(got) != len(tt.fields) {
+				t.Errorf("violations = %v, want fields %v", ia.Violations, tt.fields)
+			}
+			for _, f := range tt.fields {
+				if !got[f] {
+					t.Errorf("no violation of %s in %v", f, ia.Violations)
+				}
+			}
+		})
+	}
+}